@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/lmquang/code-review/pkg/diff"
+	"github.com/lmquang/code-review/pkg/github"
+	"github.com/lmquang/code-review/pkg/review"
+)
+
+func handleGitHubCommand(ctx context.Context) {
+	githubCmd := flag.NewFlagSet("github", flag.ExitOnError)
+	flags := addReviewFlags(githubCmd)
+	prFlag := githubCmd.Int("pr", 0, "Number of the pull request to review")
+	repoFlag := githubCmd.String("repo", "", "Repository of the pull request as 'owner/name' (defaults to $GITHUB_REPOSITORY)")
+	githubURLFlag := githubCmd.String("github-url", "", fmt.Sprintf("URL of the GitHub REST API, such as 'https://HOST/api/v3' for GitHub Enterprise Server (defaults to $GITHUB_API_URL or %s)", github.DefaultBaseURL))
+
+	err := githubCmd.Parse(os.Args[2:])
+	if err != nil {
+		fatalf("Error parsing github command: %v", err)
+	}
+	if *prFlag <= 0 {
+		fatal("Please provide the number of the pull request to review with -pr")
+	}
+
+	r := flags.newReviewer(true)
+
+	repo := firstNonEmpty(*repoFlag, os.Getenv("GITHUB_REPOSITORY"))
+	if repo == "" {
+		fatal("Please provide the repository of the pull request with -repo owner/name")
+	}
+	if err := github.ValidateRepo(repo); err != nil {
+		fatalf("Invalid -repo: %v", err)
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		fatal("GITHUB_TOKEN is not set. Please set it to a token allowed to read and comment on the pull requests of the repository.")
+	}
+	baseURL := firstNonEmpty(*githubURLFlag, os.Getenv("GITHUB_API_URL"), github.DefaultBaseURL)
+	client := github.NewClient(http.DefaultClient, baseURL, token, repo)
+
+	pr, err := client.GetPullRequest(ctx, *prFlag)
+	if err != nil {
+		fatalf("Error getting the pull request: %v", err)
+	}
+	rawDiff, err := client.GetDiff(ctx, pr.Number)
+	if err != nil {
+		fatalf("Error getting the diff: %v", err)
+	}
+
+	fmt.Fprintf(r.progress, "Reviewing pull request #%d: %s\n", pr.Number, pr.Title)
+	parsed, files, ok := r.reviewRemote(ctx, rawDiff, pr.BaseSHA, pr.HeadSHA, pr.Title, pr.Body)
+	if !ok {
+		return
+	}
+	summary, drafts := githubComments(parsed, hunksByPath(files))
+	result, err := github.Publish(ctx, client, pr, summary, drafts)
+	if err != nil {
+		fatalf("Error publishing the review: %v", err)
+	}
+	fmt.Fprintf(r.progress, "Review published on pull request #%d: %d inline comment(s) created, %d updated and %d deleted\n", pr.Number, result.Created, result.Updated, result.Deleted)
+
+	if r.failOn != "" {
+		r.checkFindings(parsed.CountAtLeast(r.failOn))
+	}
+}
+
+// githubComments splits the findings of a review into inline comments on the lines they are
+// about and a summary comment with the findings that are not on a line of the diff
+func githubComments(parsed review.Review, hunks map[string][]diff.Hunk) (string, []github.DraftComment) {
+	positions := make(map[string]map[int]int)
+	var drafts []github.DraftComment
+	rest := review.Review{Summary: parsed.Summary}
+	for _, finding := range parsed.Findings {
+		if _, found := positions[finding.File]; !found {
+			positions[finding.File] = github.Positions(hunks[finding.File])
+		}
+		// Comments are placed on the last line of multi-line findings, above which GitHub shows the diff
+		if position, found := positions[finding.File][finding.EndLine]; found && finding.EndLine > 0 {
+			drafts = append(drafts, github.DraftComment{Path: finding.File, Position: position, Body: review.Comment(finding)})
+			continue
+		}
+		rest.Findings = append(rest.Findings, finding)
+	}
+	return review.CommentSummary(rest, len(drafts)), drafts
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/lmquang/code-review/pkg/diff"
+	"github.com/lmquang/code-review/pkg/gitlab"
+	"github.com/lmquang/code-review/pkg/review"
+)
+
+func handleGitLabCommand(ctx context.Context) {
+	gitlabCmd := flag.NewFlagSet("gitlab", flag.ExitOnError)
+	flags := addReviewFlags(gitlabCmd)
+	mrFlag := gitlabCmd.Int("mr", 0, "IID of the merge request to review, as shown after '!'")
+	projectFlag := gitlabCmd.String("project", "", "ID or full path of the project of the merge request, such as 'group/project' (defaults to $CI_PROJECT_ID)")
+	gitlabURLFlag := gitlabCmd.String("gitlab-url", "", fmt.Sprintf("URL of the GitLab REST API, such as 'https://HOST/api/v4' for self-managed instances (defaults to $CI_API_V4_URL or %s)", gitlab.DefaultBaseURL))
+
+	err := gitlabCmd.Parse(os.Args[2:])
+	if err != nil {
+		fatalf("Error parsing gitlab command: %v", err)
+	}
+	if *mrFlag <= 0 {
+		fatal("Please provide the IID of the merge request to review with -mr")
+	}
+
+	r := flags.newReviewer(true)
+
+	project := firstNonEmpty(*projectFlag, os.Getenv("CI_PROJECT_ID"))
+	if project == "" {
+		fatal("Please provide the project of the merge request with -project")
+	}
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		fatal("GITLAB_TOKEN is not set. Please set it to a token with the api scope on the project.")
+	}
+	baseURL := firstNonEmpty(*gitlabURLFlag, os.Getenv("CI_API_V4_URL"), gitlab.DefaultBaseURL)
+	client := gitlab.NewClient(http.DefaultClient, baseURL, token, project)
+
+	mr, err := client.GetMergeRequest(ctx, *mrFlag)
+	if err != nil {
+		fatalf("Error getting the merge request: %v", err)
+	}
+	rawDiff, err := client.GetDiff(ctx, mr.IID)
+	if err != nil {
+		fatalf("Error getting the diff: %v", err)
+	}
+
+	fmt.Fprintf(r.progress, "Reviewing merge request !%d: %s\n", mr.IID, mr.Title)
+	parsed, files, ok := r.reviewRemote(ctx, rawDiff, mr.BaseSHA, mr.HeadSHA, mr.Title, mr.Description)
+	if !ok {
+		return
+	}
+	summary, drafts := gitlabDiscussions(parsed, mr, files)
+	result, err := gitlab.Publish(ctx, client, mr, summary, drafts)
+	if err != nil {
+		fatalf("Error publishing the review: %v", err)
+	}
+	fmt.Fprintf(r.progress, "Review published on merge request !%d: %d discussion(s) started, %d updated and %d resolved\n", mr.IID, result.Created, result.Updated, result.Resolved)
+
+	if r.failOn != "" {
+		r.checkFindings(parsed.CountAtLeast(r.failOn))
+	}
+}
+
+// gitlabDiscussions splits the findings of a review into discussions on the lines they are
+// about and a summary note with the findings that are not on a line of the diff
+func gitlabDiscussions(parsed review.Review, mr gitlab.MergeRequest, files []diff.FormattedFile) (string, []gitlab.DraftDiscussion) {
+	byPath := make(map[string]diff.FormattedFile, len(files))
+	for _, file := range files {
+		byPath[file.Path] = file
+	}
+
+	var drafts []gitlab.DraftDiscussion
+	rest := review.Review{Summary: parsed.Summary}
+	for _, finding := range parsed.Findings {
+		// Discussions are placed on the last line of multi-line findings, above which GitLab shows the diff
+		if file, found := byPath[finding.File]; found && finding.EndLine > 0 {
+			if position, found := gitlab.LinePosition(mr, file.OldPath, file.Path, file.Hunks, finding.EndLine); found {
+				drafts = append(drafts, gitlab.DraftDiscussion{Position: position, Body: review.Comment(finding)})
+				continue
+			}
+		}
+		rest.Findings = append(rest.Findings, finding)
+	}
+	return review.CommentSummary(rest, len(drafts)), drafts
+}
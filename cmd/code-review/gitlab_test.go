@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lmquang/code-review/pkg/diff"
+	"github.com/lmquang/code-review/pkg/gitlab"
+	"github.com/lmquang/code-review/pkg/review"
+)
+
+func TestGitLabDiscussions(t *testing.T) {
+	fileDiffs, err := diff.Parse("diff --git a/old.go b/new.go\n" +
+		"similarity index 90%\n" +
+		"rename from old.go\n" +
+		"rename to new.go\n" +
+		"--- a/old.go\n" +
+		"+++ b/new.go\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" package a\n" +
+		"-var x = 1\n" +
+		"+var x = 2\n")
+	assert.NoError(t, err)
+	files := []diff.FormattedFile{{Path: "new.go", OldPath: "old.go", Hunks: fileDiffs[0].Hunks}}
+	mr := gitlab.MergeRequest{IID: 1, BaseSHA: "base", StartSHA: "start", HeadSHA: "head"}
+
+	parsed := review.Review{
+		Summary: "Looks fine.",
+		Findings: []review.Finding{
+			{File: "new.go", StartLine: 2, EndLine: 2, Severity: review.SeverityMajor, Message: "x changed"},
+			{File: "new.go", StartLine: 9, EndLine: 9, Severity: review.SeverityMinor, Message: "outside the diff"},
+			{File: "other.go", Severity: review.SeverityMinor, Message: "not reviewed"},
+		},
+	}
+	summary, drafts := gitlabDiscussions(parsed, mr, files)
+
+	if assert.Len(t, drafts, 1) {
+		assert.Equal(t, gitlab.Position{PositionType: "text", BaseSHA: "base", StartSHA: "start", HeadSHA: "head", OldPath: "old.go", NewPath: "new.go", NewLine: 2}, drafts[0].Position)
+		assert.Contains(t, drafts[0].Body, "x changed")
+	}
+	assert.Contains(t, summary, "outside the diff")
+	assert.Contains(t, summary, "not reviewed")
+	assert.NotContains(t, summary, "x changed")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lmquang/code-review/pkg/diff"
+	"github.com/lmquang/code-review/pkg/git"
+	"github.com/lmquang/code-review/pkg/gpt"
+	"github.com/lmquang/code-review/pkg/history"
+)
+
+// reviewIncremental reviews the commits added to a branch since its last review, checking
+// which findings of that review still apply, and records the review for the next run. The
+// whole branch is reviewed when it was never reviewed or its last reviewed commit was rewritten.
+func (r *reviewer) reviewIncremental(ctx context.Context, diffOptions git.DiffOptions, reviews history.IHistory) {
+	branch := diffOptions.Head
+	if branch == "" {
+		var err error
+		branch, err = r.gitClient.GetCurrentBranch()
+		if err != nil {
+			fatalf("Error finding the branch to review incrementally: %v", err)
+		}
+	}
+
+	_, head, err := r.gitClient.ResolveCommit(branch)
+	if err != nil {
+		fatalf("Error resolving %s: %v", branch, err)
+	}
+
+	last, found, err := reviews.Last(branch)
+	if err != nil {
+		fatalf("Error reading the last review of %s: %v", branch, err)
+	}
+	if found && last.Head == head {
+		fmt.Fprintf(r.progress, "No new commits on %s since the last review at %s.\n", branch, shortHash(last.Head))
+		r.finish(ctx, last.Review, nil)
+		return
+	}
+
+	var from string
+	var reviewOptions gpt.ReviewOptions
+	if found {
+		reviewOptions.PreviousReview = last.Review
+		if base, err := r.gitClient.GetBaseRevision(git.DiffOptions{Base: last.Head, Head: head}); err == nil && base == last.Head {
+			from = last.Head
+			fmt.Fprintf(r.progress, "Reviewing commits added to %s since the last review at %s\n", branch, shortHash(last.Head))
+		} else {
+			fmt.Fprintf(r.progress, "The last reviewed commit %s is no longer part of %s, reviewing the whole branch\n", shortHash(last.Head), branch)
+		}
+	}
+
+	if from != "" {
+		commits, err := r.gitClient.GetCommits(from, head)
+		if err != nil {
+			fatalf("Error getting commits: %v", err)
+		}
+		for _, commit := range commits {
+			reviewOptions.CommitMessages = append(reviewOptions.CommitMessages, commit.Message)
+		}
+	} else {
+		from, err = r.gitClient.GetBaseRevision(diffOptions)
+		if err != nil {
+			fatalf("Error getting base revision: %v", err)
+		}
+	}
+
+	rawDiff, err := r.gitClient.GetRangeDiff(from, head)
+	if err != nil {
+		fatalf("Error getting git diff: %v", err)
+	}
+
+	printer := r.printer("GPT Review:\n")
+	gptResponse := ""
+	var files []diff.FormattedFile
+	if rawDiff != "" {
+		gptResponse, files, err = r.reviewDiff(ctx, rawDiff, from, printer.options(reviewOptions))
+		if err != nil {
+			fatalf("Error sending to GPT: %v", err)
+		}
+	}
+
+	// Commits without reviewable changes keep the findings of the previous review, which are
+	// not about the lines of these changes
+	entry := history.Entry{Head: head, Review: gptResponse, ReviewedAt: time.Now()}
+	if gptResponse == "" {
+		entry.Review = last.Review
+		files = nil
+	}
+	if err := reviews.Save(branch, entry); err != nil {
+		log.Printf("Warning: the review will not be used by the next incremental review: %v", err)
+	}
+
+	switch {
+	case rawDiff == "":
+		fmt.Fprintln(r.progress, "No changes detected.")
+	case gptResponse == "":
+		fmt.Fprintln(r.progress, "No changes to review after applying ignore patterns.")
+	}
+	if entry.Review != "" {
+		printer.print(entry.Review)
+	}
+
+	r.finish(ctx, entry.Review, hunksByPath(files))
+}
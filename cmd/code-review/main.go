@@ -1,42 +1,136 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v2"
 
+	"github.com/lmquang/code-review/pkg/cache"
 	"github.com/lmquang/code-review/pkg/diff"
 	"github.com/lmquang/code-review/pkg/git"
 	"github.com/lmquang/code-review/pkg/gpt"
+	"github.com/lmquang/code-review/pkg/gpt/provider"
+	"github.com/lmquang/code-review/pkg/history"
+	"github.com/lmquang/code-review/pkg/retry"
+	"github.com/lmquang/code-review/pkg/review"
 )
 
 type Config struct {
 	OpenAIAPIKey string `yaml:"openai_api_key"`
 	OpenAIModel  string `yaml:"openai_model"`
+	GitBackend   string `yaml:"git_backend"`
+	TokenBudget  int    `yaml:"token_budget"`
+	Concurrency  int    `yaml:"concurrency"`
+	// RequestsPerMinute and TokensPerMinute are the rate limits of the API account, zero for no limit
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	TokensPerMinute   int `yaml:"tokens_per_minute"`
+	// Provider selects the chat provider: openai (default), azure, anthropic or ollama
+	Provider        string `yaml:"provider"`
+	AzureAPIKey     string `yaml:"azure_api_key"`
+	AzureEndpoint   string `yaml:"azure_endpoint"`
+	AzureDeployment string `yaml:"azure_deployment"`
+	AzureAPIVersion string `yaml:"azure_api_version"`
+	AnthropicAPIKey string `yaml:"anthropic_api_key"`
+	AnthropicModel  string `yaml:"anthropic_model"`
+	OllamaBaseURL   string `yaml:"ollama_base_url"`
+	OllamaModel     string `yaml:"ollama_model"`
+	// OpenAIBaseURL points the openai provider to another server implementing its API
+	OpenAIBaseURL      string `yaml:"openai_base_url"`
+	OpenAIOrganization string `yaml:"openai_organization"`
+	// Headers are added to every request sent to the provider
+	Headers map[string]string `yaml:"headers"`
+	// MaxTokens is the maximum number of tokens of each answer
+	MaxTokens int `yaml:"max_tokens"`
+	// Temperature, TopP and Seed are left to the provider's defaults when not set
+	Temperature     *float64 `yaml:"temperature,omitempty"`
+	TopP            *float64 `yaml:"top_p,omitempty"`
+	Seed            *int     `yaml:"seed,omitempty"`
+	ReasoningEffort string   `yaml:"reasoning_effort"`
+}
+
+// Exit codes distinguishing findings that fail the review from errors of the tool itself
+const (
+	exitFindings = 1
+	exitError    = 2
+)
+
+// fatal logs an error of the tool and exits
+func fatal(v ...interface{}) {
+	log.Print(v...)
+	os.Exit(exitError)
+}
+
+// fatalf logs a formatted error of the tool and exits
+func fatalf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+	os.Exit(exitError)
+}
+
+// headerFlag collects repeated 'Name: value' flags into a set of headers
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	var headers []string
+	for name, value := range h {
+		headers = append(headers, name+": "+value)
+	}
+	sort.Strings(headers)
+	return strings.Join(headers, ", ")
+}
+
+func (h headerFlag) Set(value string) error {
+	name, headerValue, found := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return fmt.Errorf("expected 'Name: value', got %q", value)
+	}
+	h[http.CanonicalHeaderKey(name)] = strings.TrimSpace(headerValue)
+	return nil
 }
 
 func main() {
 	if len(os.Args) < 2 {
 		fmt.Println("Usage: code-review <command> [<args>]")
 		fmt.Println("Commands:")
-		fmt.Println(" set    Set the OpenAI API Key and/or model")
+		fmt.Println(" set    Set the provider, API keys, models and other defaults")
 		fmt.Println(" review Run the code review process")
+		fmt.Println(" github Review a GitHub pull request and comment on it")
+		fmt.Println(" gitlab Review a GitLab merge request and comment on it")
+		fmt.Println(" cache  Manage the review cache ('cache clear' removes all cached reviews)")
 		return
 	}
 
+	// Ctrl-C cancels the requests in flight instead of leaving them running
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	switch os.Args[1] {
 	case "set", "s":
 		handleSetCommand()
 	case "review", "r":
-		handleReviewCommand()
+		handleReviewCommand(ctx)
+	case "github":
+		handleGitHubCommand(ctx)
+	case "gitlab":
+		handleGitLabCommand(ctx)
+	case "cache":
+		handleCacheCommand()
 	default:
 		fmt.Printf("Unknown command: %s\n", os.Args[1])
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 }
 
@@ -44,14 +138,37 @@ func handleSetCommand() {
 	setCmd := flag.NewFlagSet("set", flag.ExitOnError)
 	openAIAPIKey := setCmd.String("openai-api-key", "", "Set the OpenAI API Key")
 	openAIModel := setCmd.String("openai-model", "", "Set the OpenAI Model")
+	gitBackend := setCmd.String("git-backend", "", "Set the git backend ('exec' or 'go-git')")
+	tokenBudget := setCmd.Int("token-budget", 0, "Set the maximum estimated prompt tokens per request")
+	concurrency := setCmd.Int("concurrency", 0, "Set the maximum number of review requests in flight")
+	requestsPerMinute := setCmd.Int("requests-per-minute", 0, "Set the requests per minute allowed by the API account")
+	tokensPerMinute := setCmd.Int("tokens-per-minute", 0, "Set the tokens per minute allowed by the API account")
+	providerName := setCmd.String("provider", "", "Set the chat provider ('openai', 'azure', 'anthropic' or 'ollama')")
+	azureAPIKey := setCmd.String("azure-api-key", "", "Set the Azure OpenAI API Key")
+	azureEndpoint := setCmd.String("azure-endpoint", "", "Set the Azure OpenAI endpoint (e.g., 'https://NAME.openai.azure.com')")
+	azureDeployment := setCmd.String("azure-deployment", "", "Set the Azure OpenAI deployment")
+	azureAPIVersion := setCmd.String("azure-api-version", "", "Set the Azure OpenAI API version")
+	anthropicAPIKey := setCmd.String("anthropic-api-key", "", "Set the Anthropic API Key")
+	anthropicModel := setCmd.String("anthropic-model", "", "Set the Anthropic Model")
+	ollamaBaseURL := setCmd.String("ollama-base-url", "", "Set the URL of the Ollama server")
+	ollamaModel := setCmd.String("ollama-model", "", "Set the Ollama Model")
+	openAIBaseURL := setCmd.String("openai-base-url", "", "Set the URL of an OpenAI-compatible server (e.g., 'http://localhost:8080/v1')")
+	openAIOrganization := setCmd.String("openai-organization", "", "Set the OpenAI organization ID")
+	headers := headerFlag{}
+	setCmd.Var(headers, "header", "Add a header sent with every request, as 'Name: value'; an empty value removes it (repeatable)")
+	maxTokens := setCmd.Int("max-tokens", 0, "Set the maximum number of tokens of each answer")
+	temperature := setCmd.Float64("temperature", 0, "Set the sampling temperature")
+	topP := setCmd.Float64("top-p", 0, "Set the nucleus sampling probability")
+	seed := setCmd.Int("seed", 0, "Set the seed for more deterministic answers, where the provider supports it")
+	reasoningEffort := setCmd.String("reasoning-effort", "", "Set the reasoning effort of reasoning models (e.g., 'low', 'medium' or 'high')")
 
 	err := setCmd.Parse(os.Args[2:])
 	if err != nil {
-		log.Fatalf("Error parsing set command: %v", err)
+		fatalf("Error parsing set command: %v", err)
 	}
 
-	if *openAIAPIKey == "" && *openAIModel == "" {
-		log.Fatal("Please provide at least one of -openai-api-key or -openai-model")
+	if setCmd.NFlag() == 0 {
+		fatal("Please provide at least one setting, see 'code-review set -h'")
 	}
 
 	config, err := loadConfig()
@@ -65,20 +182,166 @@ func handleSetCommand() {
 	if *openAIModel != "" {
 		config.OpenAIModel = *openAIModel
 	}
+	if *gitBackend != "" {
+		config.GitBackend = *gitBackend
+	}
+	if *tokenBudget > 0 {
+		config.TokenBudget = *tokenBudget
+	}
+	if *concurrency > 0 {
+		config.Concurrency = *concurrency
+	}
+	if *requestsPerMinute > 0 {
+		config.RequestsPerMinute = *requestsPerMinute
+	}
+	if *tokensPerMinute > 0 {
+		config.TokensPerMinute = *tokensPerMinute
+	}
+	if *providerName != "" {
+		config.Provider = *providerName
+	}
+	if *azureAPIKey != "" {
+		config.AzureAPIKey = *azureAPIKey
+	}
+	if *azureEndpoint != "" {
+		config.AzureEndpoint = *azureEndpoint
+	}
+	if *azureDeployment != "" {
+		config.AzureDeployment = *azureDeployment
+	}
+	if *azureAPIVersion != "" {
+		config.AzureAPIVersion = *azureAPIVersion
+	}
+	if *anthropicAPIKey != "" {
+		config.AnthropicAPIKey = *anthropicAPIKey
+	}
+	if *anthropicModel != "" {
+		config.AnthropicModel = *anthropicModel
+	}
+	if *ollamaBaseURL != "" {
+		config.OllamaBaseURL = *ollamaBaseURL
+	}
+	if *ollamaModel != "" {
+		config.OllamaModel = *ollamaModel
+	}
+	if *openAIBaseURL != "" {
+		config.OpenAIBaseURL = *openAIBaseURL
+	}
+	if *openAIOrganization != "" {
+		config.OpenAIOrganization = *openAIOrganization
+	}
+	for name, value := range headers {
+		if value == "" {
+			delete(config.Headers, name)
+			continue
+		}
+		if config.Headers == nil {
+			config.Headers = make(map[string]string)
+		}
+		config.Headers[name] = value
+	}
+	if *maxTokens > 0 {
+		config.MaxTokens = *maxTokens
+	}
+	setFlags := visitedFlags(setCmd)
+	if setFlags["temperature"] {
+		config.Temperature = temperature
+	}
+	if setFlags["top-p"] {
+		config.TopP = topP
+	}
+	if setFlags["seed"] {
+		config.Seed = seed
+	}
+	if *reasoningEffort != "" {
+		config.ReasoningEffort = *reasoningEffort
+	}
+	if err := validateGeneration(config); err != nil {
+		fatal(err)
+	}
 
 	if err := saveConfig(config); err != nil {
-		log.Fatalf("Error saving config: %v", err)
+		fatalf("Error saving config: %v", err)
 	}
 	fmt.Println("Configuration has been saved successfully.")
 }
 
-func handleReviewCommand() {
-	reviewCmd := flag.NewFlagSet("review", flag.ExitOnError)
-	ignoreFlag := reviewCmd.String("ignore", "", "Comma-separated list of files or extensions to ignore (e.g., '*.yaml,*.json,docs.go')")
+// reviewFlags are the flags of the commands running a review, selecting the git backend, the
+// provider and how the changes are sent to it
+type reviewFlags struct {
+	flags             *flag.FlagSet
+	ignore            *string
+	provider          *string
+	gitBackend        *string
+	context           *string
+	contextLines      *int
+	tokenBudget       *int
+	split             *string
+	concurrency       *int
+	requestsPerMinute *int
+	tokensPerMinute   *int
+	noCache           *bool
+	noStream          *bool
+	timeout           *time.Duration
+	retries           *int
+	maxTokens         *int
+	temperature       *float64
+	topP              *float64
+	seed              *int
+	reasoningEffort   *string
+	failOn            *string
+}
 
-	err := reviewCmd.Parse(os.Args[2:])
+// addReviewFlags defines the flags shared by the commands running a review
+func addReviewFlags(flags *flag.FlagSet) *reviewFlags {
+	return &reviewFlags{
+		flags:             flags,
+		ignore:            flags.String("ignore", "", "Comma-separated list of files or extensions to ignore (e.g., '*.yaml,*.json,docs.go')"),
+		provider:          flags.String("provider", "", "Chat provider to use: 'openai', 'azure', 'anthropic' or 'ollama' (defaults to the configured provider)"),
+		gitBackend:        flags.String("git-backend", "", "Git backend to use: 'exec' (git binary) or 'go-git' (pure Go)"),
+		context:           flags.String("context", string(diff.ContextFull), "Original content to send: 'full' (whole files), 'hunks' (lines around each hunk) or 'function' (enclosing function of each hunk)"),
+		contextLines:      flags.Int("context-lines", diff.DefaultContextLines, "Number of lines around each hunk to send with -context hunks"),
+		tokenBudget:       flags.Int("token-budget", 0, fmt.Sprintf("Maximum estimated prompt tokens per request; larger changes are reviewed in batches and merged (default %d)", gpt.DefaultTokenBudget)),
+		split:             flags.String("split", string(diff.SplitBudget), "How files are grouped into requests: 'budget' (as few as the token budget allows), 'file' or 'package'. Reviews are cached per request, so 'file' reuses the reviews of unchanged files after a fixup, at the cost of a request per file and a merge request"),
+		concurrency:       flags.Int("concurrency", 0, fmt.Sprintf("Maximum number of review requests in flight (default %d)", defaultConcurrency)),
+		requestsPerMinute: flags.Int("requests-per-minute", 0, "Requests per minute allowed by the API account (default unlimited)"),
+		tokensPerMinute:   flags.Int("tokens-per-minute", 0, "Tokens per minute allowed by the API account (default unlimited)"),
+		noCache:           flags.Bool("no-cache", false, "Do not reuse or store cached reviews"),
+		noStream:          flags.Bool("no-stream", false, "Print the review once it is complete instead of as it is generated"),
+		timeout:           flags.Duration("timeout", retry.DefaultTimeout, "Maximum time for a single request to the provider, including reading the answer (0 for no limit)"),
+		retries:           flags.Int("retries", retry.DefaultMaxRetries, "Number of times a request failing with a rate limit, server or network error is retried"),
+		maxTokens:         flags.Int("max-tokens", 0, fmt.Sprintf("Maximum number of tokens of each answer; longer answers are continued in further requests (default %d)", gpt.DefaultMaxTokens)),
+		temperature:       flags.Float64("temperature", 0, "Sampling temperature (defaults to the configured value or the provider's default)"),
+		topP:              flags.Float64("top-p", 0, "Nucleus sampling probability (defaults to the configured value or the provider's default)"),
+		seed:              flags.Int("seed", 0, "Seed for more deterministic answers, where the provider supports it"),
+		reasoningEffort:   flags.String("reasoning-effort", "", "Reasoning effort of reasoning models (e.g., 'low', 'medium' or 'high')"),
+		failOn:            flags.String("fail-on", "", "Exit with code 1 when the review has findings of this severity or higher: 'info', 'minor', 'major' or 'critical'"),
+	}
+}
+
+// newReviewer creates the reviewer configured by the flags, the environment and the config
+// file, in this order of precedence. Without a repository, the reviewer has no git client
+// unless one is required.
+func (f *reviewFlags) newReviewer(requireRepository bool) *reviewer {
+	contextStrategy, err := diff.ParseContextStrategy(*f.context)
+	if err != nil {
+		fatalf("Invalid -context: %v", err)
+	}
+	if *f.contextLines < 0 {
+		fatal("-context-lines cannot be negative")
+	}
+	splitStrategy, err := diff.ParseSplitStrategy(*f.split)
 	if err != nil {
-		log.Fatalf("Error parsing review command: %v", err)
+		fatalf("Invalid -split: %v", err)
+	}
+	if *f.timeout < 0 || *f.retries < 0 {
+		fatal("-timeout and -retries cannot be negative")
+	}
+	var failOn review.Severity
+	if *f.failOn != "" {
+		if failOn, err = review.ParseSeverity(*f.failOn); err != nil {
+			fatalf("Invalid -fail-on: %v", err)
+		}
 	}
 
 	err = godotenv.Load()
@@ -86,46 +349,322 @@ func handleReviewCommand() {
 		log.Printf("Warning: Error loading .env file: %v", err)
 	}
 
-	config := parseConfig(*ignoreFlag)
+	config := parseConfig(*f.ignore)
 
-	gitClient := git.NewClient()
-	diffFormatter := diff.NewFormatter(diff.SplitAndTrimPatterns(*ignoreFlag))
-	gptClient := gpt.NewOpenAIClient(config.OpenAIAPIKey)
-	if config.OpenAIModel != "" {
-		gptClient.Client().SetModel(config.OpenAIModel)
+	if *f.gitBackend != "" {
+		config.GitBackend = *f.gitBackend
 	}
+	gitClient, err := git.NewBackend(config.GitBackend)
+	if err != nil {
+		if requireRepository {
+			fatalf("Error creating git client: %v", err)
+		}
+		log.Printf("Warning: Error creating git client: %v", err)
+	}
+	diffFormatter := diff.NewFormatter(gitClient, diff.SplitAndTrimPatterns(*f.ignore))
+	diffFormatter.SetContext(contextStrategy, *f.contextLines)
 
-	diff, changedFiles, err := gitClient.GetDiff()
+	if *f.provider != "" {
+		config.Provider = *f.provider
+	}
+	opts, err := providerOptions(config)
+	if err != nil {
+		fatal(err)
+	}
+	opts.MaxRetries = *f.retries
+	opts.Timeout = *f.timeout
+	chat, err := gpt.NewProvider(opts)
 	if err != nil {
-		log.Fatalf("Error getting git diff: %v", err)
+		fatalf("Error creating provider: %v", err)
 	}
+	gptClient := gpt.NewClient(chat)
 
-	if diff == "" {
-		fmt.Println("No changes detected in the current branch.")
-		return
+	if *f.maxTokens > 0 {
+		config.MaxTokens = *f.maxTokens
+	}
+	visited := visitedFlags(f.flags)
+	if visited["temperature"] {
+		config.Temperature = f.temperature
+	}
+	if visited["top-p"] {
+		config.TopP = f.topP
+	}
+	if visited["seed"] {
+		config.Seed = f.seed
+	}
+	if *f.reasoningEffort != "" {
+		config.ReasoningEffort = *f.reasoningEffort
+	}
+	if err := validateGeneration(config); err != nil {
+		fatal(err)
+	}
+	gptClient.SetGeneration(gpt.GenerationOptions{
+		MaxTokens:       config.MaxTokens,
+		Temperature:     config.Temperature,
+		TopP:            config.TopP,
+		Seed:            config.Seed,
+		ReasoningEffort: config.ReasoningEffort,
+	})
+
+	if *f.tokenBudget > 0 {
+		config.TokenBudget = *f.tokenBudget
+	}
+	if config.TokenBudget <= 0 {
+		config.TokenBudget = gpt.DefaultTokenBudget
+	}
+	if *f.concurrency > 0 {
+		config.Concurrency = *f.concurrency
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaultConcurrency
+	}
+	if *f.requestsPerMinute > 0 {
+		config.RequestsPerMinute = *f.requestsPerMinute
+	}
+	if *f.tokensPerMinute > 0 {
+		config.TokensPerMinute = *f.tokensPerMinute
+	}
+	gptClient.SetRateLimit(config.RequestsPerMinute, config.TokensPerMinute)
+	if !*f.noCache {
+		cacheDir, err := cache.DefaultDir()
+		if err != nil {
+			log.Printf("Warning: reviews will not be cached: %v", err)
+		} else {
+			gptClient.SetCache(cache.NewFileCache(cacheDir))
+		}
+	}
+
+	return &reviewer{
+		gitClient:     gitClient,
+		diffFormatter: diffFormatter,
+		gptClient:     gptClient,
+		tokenBudget:   config.TokenBudget,
+		split:         splitStrategy,
+		concurrency:   config.Concurrency,
+		stream:        !*f.noStream,
+		format:        review.FormatText,
+		report:        os.Stdout,
+		progress:      os.Stdout,
+		failOn:        failOn,
+	}
+}
+
+// reviewTarget holds the flags of the review command selecting the changes to review and how
+// they are reviewed and reported
+type reviewTarget struct {
+	base, head  string
+	staged      bool
+	worktree    bool
+	commit      string
+	rangeSpec   string
+	patch       string
+	perCommit   bool
+	incremental bool
+	output      string
+}
+
+// check rejects the flags that cannot be combined and parses the format of the review
+func (t reviewTarget) check(formatName string) (review.Format, error) {
+	modes := 0
+	for _, set := range []bool{t.staged, t.worktree, t.commit != "", t.rangeSpec != "", t.patch != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return "", errors.New("Please provide only one of -staged, -worktree, -commit, -range or -patch")
+	}
+	// With -patch, -base selects the commit the patch applies to
+	if modes == 1 && ((t.base != "" && t.patch == "") || t.head != "") {
+		return "", errors.New("-base and -head cannot be combined with -staged, -worktree, -commit, -range or -patch")
+	}
+	if t.perCommit && (t.staged || t.worktree || t.commit != "" || t.patch != "") {
+		return "", errors.New("-per-commit can only be used when reviewing a branch or a -range")
+	}
+	if t.incremental && (modes > 0 || t.perCommit) {
+		return "", errors.New("-incremental can only be used when reviewing a branch, without -per-commit")
+	}
+	format, err := review.ParseFormat(formatName)
+	if err != nil {
+		return "", fmt.Errorf("Invalid -format: %v", err)
+	}
+	if t.perCommit && (format != review.FormatText || t.output != "") {
+		return "", errors.New("-format and -output cannot be combined with -per-commit")
+	}
+	return format, nil
+}
+
+func handleReviewCommand(ctx context.Context) {
+	reviewCmd := flag.NewFlagSet("review", flag.ExitOnError)
+	flags := addReviewFlags(reviewCmd)
+	baseFlag := reviewCmd.String("base", "", "Base ref to compare against (defaults to the default branch of origin)")
+	headFlag := reviewCmd.String("head", "", "Head ref containing the changes to review (defaults to HEAD)")
+	stagedFlag := reviewCmd.Bool("staged", false, "Review staged changes against HEAD")
+	worktreeFlag := reviewCmd.Bool("worktree", false, "Review uncommitted changes in the working tree against HEAD")
+	commitFlag := reviewCmd.String("commit", "", "Review a single commit")
+	rangeFlag := reviewCmd.String("range", "", "Review a commit range (e.g., 'A..B' or 'A...B')")
+	patchFlag := reviewCmd.String("patch", "", "Review a patch file produced by 'git diff' or 'git format-patch' instead of the changes of the repository ('-' for stdin)")
+	perCommitFlag := reviewCmd.Bool("per-commit", false, "Review each commit of the branch or range separately")
+	incrementalFlag := reviewCmd.Bool("incremental", false, "Review only the commits added to the branch since its last review, and check which earlier findings still apply")
+	formatFlag := reviewCmd.String("format", string(review.FormatText), "Format of the review: 'text', 'json', 'sarif', 'markdown' or 'checkstyle'")
+	outputFlag := reviewCmd.String("output", "", "File to write the review to (defaults to stdout)")
+
+	err := reviewCmd.Parse(os.Args[2:])
+	if err != nil {
+		fatalf("Error parsing review command: %v", err)
+	}
+
+	target := reviewTarget{
+		base:        *baseFlag,
+		head:        *headFlag,
+		staged:      *stagedFlag,
+		worktree:    *worktreeFlag,
+		commit:      *commitFlag,
+		rangeSpec:   *rangeFlag,
+		patch:       *patchFlag,
+		perCommit:   *perCommitFlag,
+		incremental: *incrementalFlag,
+		output:      *outputFlag,
+	}
+	format, err := target.check(*formatFlag)
+	if err != nil {
+		fatal(err)
+	}
+
+	r := flags.newReviewer(*patchFlag == "")
+	r.format = format
+	r.output = *outputFlag
+	// Structured reviews written to stdout are kept apart from the progress messages and the
+	// review as it is generated, which go to stderr instead
+	if format != review.FormatText && *outputFlag == "" {
+		r.progress = os.Stderr
 	}
+	gitClient := r.gitClient
 
-	originalContent, formattedDiff, errors := diffFormatter.Format(diff, changedFiles)
-	if len(errors) > 0 {
-		fmt.Println("Encountered errors while processing some files:")
-		for _, err := range errors {
-			fmt.Printf("- %v\n", err)
+	if *incrementalFlag {
+		gitDir, err := gitClient.GetGitDir()
+		if err != nil {
+			fatalf("Error locating the review history: %v", err)
 		}
-		fmt.Println("Continuing with the files that were processed successfully.")
+		r.reviewIncremental(ctx, git.DiffOptions{Base: *baseFlag, Head: *headFlag}, history.NewFileHistory(history.Path(gitDir)))
+		return
 	}
 
-	if formattedDiff == "" {
-		fmt.Println("No changes to review after applying ignore patterns.")
+	if *perCommitFlag {
+		var from, to string
+		if *rangeFlag != "" {
+			from, to, err = gitClient.ResolveRange(*rangeFlag)
+		} else {
+			diffOptions := git.DiffOptions{Base: *baseFlag, Head: *headFlag}
+			from, err = gitClient.GetBaseRevision(diffOptions)
+			to = *headFlag
+			if to == "" {
+				to = "HEAD"
+			}
+		}
+		if err != nil {
+			fatalf("Error resolving revisions: %v", err)
+		}
+
+		r.reviewCommits(ctx, from, to)
 		return
 	}
 
-	gptResponse, err := gptClient.Review(originalContent, formattedDiff)
-	if err != nil {
-		log.Fatalf("Error sending to GPT: %v", err)
+	var (
+		rawDiff       string
+		baseRevision  string
+		reviewOptions gpt.ReviewOptions
+	)
+	if *patchFlag != "" {
+		rawDiff, err = readPatch(*patchFlag)
+		if err != nil {
+			fatalf("Error reading patch: %v", err)
+		}
+		if err := checkPatch(rawDiff); err != nil {
+			fatalf("Error reading patch: %v", err)
+		}
+		baseRevision = patchBaseRevision(gitClient, firstNonEmpty(*baseFlag, "HEAD"))
+		reviewOptions.CommitMessages = patchMessages(rawDiff)
+	} else if *commitFlag != "" || *rangeFlag != "" {
+		var from, to string
+		if *commitFlag != "" {
+			from, to, err = gitClient.ResolveCommit(*commitFlag)
+		} else {
+			from, to, err = gitClient.ResolveRange(*rangeFlag)
+		}
+		if err != nil {
+			fatalf("Error resolving revisions: %v", err)
+		}
+
+		rawDiff, err = gitClient.GetRangeDiff(from, to)
+		if err != nil {
+			fatalf("Error getting git diff: %v", err)
+		}
+		baseRevision = from
+
+		commits, err := gitClient.GetCommits(from, to)
+		if err != nil {
+			fatalf("Error getting commits: %v", err)
+		}
+		for _, commit := range commits {
+			reviewOptions.CommitMessages = append(reviewOptions.CommitMessages, commit.Message)
+		}
+	} else {
+		diffOptions := git.DiffOptions{
+			Base: *baseFlag,
+			Head: *headFlag,
+		}
+		if *stagedFlag {
+			diffOptions.Mode = git.DiffModeStaged
+		} else if *worktreeFlag {
+			diffOptions.Mode = git.DiffModeWorktree
+		}
+
+		rawDiff, err = gitClient.GetDiff(diffOptions)
+		if err != nil {
+			fatalf("Error getting git diff: %v", err)
+		}
+
+		baseRevision, err = gitClient.GetBaseRevision(diffOptions)
+		if err != nil {
+			fatalf("Error getting base revision: %v", err)
+		}
+	}
+
+	gptResponse := ""
+	var files []diff.FormattedFile
+	if rawDiff == "" {
+		fmt.Fprintln(r.progress, "No changes detected.")
+	} else {
+		printer := r.printer("GPT Review:\n")
+		gptResponse, files, err = r.reviewDiff(ctx, rawDiff, baseRevision, printer.options(reviewOptions))
+		if err != nil {
+			fatalf("Error sending to GPT: %v", err)
+		}
+		if gptResponse == "" {
+			fmt.Fprintln(r.progress, "No changes to review after applying ignore patterns.")
+		} else {
+			printer.print(gptResponse)
+		}
 	}
 
-	fmt.Println("GPT Review:")
-	fmt.Println(gptResponse)
+	r.finish(ctx, gptResponse, hunksByPath(files))
+}
+
+func handleCacheCommand() {
+	if len(os.Args) < 3 || os.Args[2] != "clear" {
+		fmt.Println("Usage: code-review cache clear")
+		os.Exit(exitError)
+	}
+
+	cacheDir, err := cache.DefaultDir()
+	if err != nil {
+		fatalf("Error locating cache: %v", err)
+	}
+	if err := cache.NewFileCache(cacheDir).Clear(); err != nil {
+		fatalf("Error clearing cache: %v", err)
+	}
+	fmt.Printf("Cache cleared: %s\n", cacheDir)
 }
 
 func parseConfig(ignoreFlag string) Config {
@@ -137,14 +676,85 @@ func parseConfig(ignoreFlag string) Config {
 	if config.OpenAIAPIKey == "" {
 		config.OpenAIAPIKey = os.Getenv("OPENAI_API_KEY")
 	}
-
-	if config.OpenAIAPIKey == "" {
-		log.Fatal("OPENAI_API_KEY is not set. Please set it using 'code-review set -openai-api-key YOUR_API_KEY' or as an environment variable.")
+	if config.OpenAIBaseURL == "" {
+		config.OpenAIBaseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+	if config.OpenAIOrganization == "" {
+		config.OpenAIOrganization = os.Getenv("OPENAI_ORG_ID")
+	}
+	if config.AzureAPIKey == "" {
+		config.AzureAPIKey = os.Getenv("AZURE_OPENAI_API_KEY")
+	}
+	if config.AnthropicAPIKey == "" {
+		config.AnthropicAPIKey = os.Getenv("ANTHROPIC_API_KEY")
 	}
 
 	return config
 }
 
+// providerOptions configures the chat provider selected in the config, checking that the
+// settings it cannot work without are present
+func providerOptions(config Config) (gpt.ProviderOptions, error) {
+	opts := gpt.ProviderOptions{Provider: config.Provider, Headers: config.Headers}
+
+	switch config.Provider {
+	case "", provider.OpenAI:
+		// Other servers implementing the OpenAI API may not require a key
+		if config.OpenAIAPIKey == "" && config.OpenAIBaseURL == "" {
+			return opts, errors.New("OPENAI_API_KEY is not set. Please set it using 'code-review set -openai-api-key YOUR_API_KEY' or as an environment variable.")
+		}
+		opts.APIKey = config.OpenAIAPIKey
+		opts.Model = config.OpenAIModel
+		opts.BaseURL = config.OpenAIBaseURL
+		opts.Organization = config.OpenAIOrganization
+	case provider.Azure:
+		if config.AzureAPIKey == "" {
+			return opts, errors.New("AZURE_OPENAI_API_KEY is not set. Please set it using 'code-review set -azure-api-key YOUR_API_KEY' or as an environment variable.")
+		}
+		if config.AzureEndpoint == "" || config.AzureDeployment == "" {
+			return opts, errors.New("The Azure OpenAI endpoint and deployment are not set. Please set them using 'code-review set -azure-endpoint URL -azure-deployment NAME'.")
+		}
+		opts.APIKey = config.AzureAPIKey
+		opts.BaseURL = config.AzureEndpoint
+		opts.Model = config.AzureDeployment
+		opts.APIVersion = config.AzureAPIVersion
+	case provider.Anthropic:
+		if config.AnthropicAPIKey == "" {
+			return opts, errors.New("ANTHROPIC_API_KEY is not set. Please set it using 'code-review set -anthropic-api-key YOUR_API_KEY' or as an environment variable.")
+		}
+		opts.APIKey = config.AnthropicAPIKey
+		opts.Model = config.AnthropicModel
+	case provider.Ollama:
+		opts.BaseURL = config.OllamaBaseURL
+		opts.Model = config.OllamaModel
+	}
+	return opts, nil
+}
+
+// visitedFlags returns the names of the flags given on the command line, for settings whose
+// zero value is meaningful
+func visitedFlags(flags *flag.FlagSet) map[string]bool {
+	visited := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		visited[f.Name] = true
+	})
+	return visited
+}
+
+// validateGeneration checks that the generation parameters are within the ranges providers accept
+func validateGeneration(config Config) error {
+	if config.MaxTokens < 0 {
+		return errors.New("-max-tokens cannot be negative")
+	}
+	if config.Temperature != nil && (*config.Temperature < 0 || *config.Temperature > 2) {
+		return fmt.Errorf("-temperature must be between 0 and 2, got %v", *config.Temperature)
+	}
+	if config.TopP != nil && (*config.TopP < 0 || *config.TopP > 1) {
+		return fmt.Errorf("-top-p must be between 0 and 1, got %v", *config.TopP)
+	}
+	return nil
+}
+
 func saveConfig(config Config) error {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
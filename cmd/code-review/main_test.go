@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lmquang/code-review/pkg/review"
+)
+
+func TestReviewTargetCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     reviewTarget
+		format     string
+		wantFormat review.Format
+		wantErr    string
+	}{
+		{name: "Branch", format: "text", wantFormat: review.FormatText},
+		{name: "Branch as JSON", format: "json", wantFormat: review.FormatJSON},
+		{name: "Two modes", target: reviewTarget{staged: true, commit: "abc"}, format: "text", wantErr: "Please provide only one of"},
+		{name: "Base with a mode", target: reviewTarget{worktree: true, base: "main"}, format: "text", wantErr: "-base and -head cannot be combined"},
+		{name: "Base with a patch", target: reviewTarget{patch: "fix.patch", base: "main"}, format: "text", wantFormat: review.FormatText},
+		{name: "Per commit on a branch", target: reviewTarget{perCommit: true, base: "main", head: "feature"}, format: "text", wantFormat: review.FormatText},
+		{name: "Per commit on a range", target: reviewTarget{perCommit: true, rangeSpec: "main..feature"}, format: "text", wantFormat: review.FormatText},
+		{name: "Per commit with staged changes", target: reviewTarget{perCommit: true, staged: true}, format: "text", wantErr: "-per-commit can only be used"},
+		{name: "Per commit with the working tree", target: reviewTarget{perCommit: true, worktree: true}, format: "text", wantErr: "-per-commit can only be used"},
+		{name: "Per commit with a commit", target: reviewTarget{perCommit: true, commit: "abc"}, format: "text", wantErr: "-per-commit can only be used"},
+		{name: "Per commit with a patch", target: reviewTarget{perCommit: true, patch: "fix.patch"}, format: "text", wantErr: "-per-commit can only be used"},
+		{name: "Per commit with a format", target: reviewTarget{perCommit: true}, format: "sarif", wantErr: "-format and -output cannot be combined with -per-commit"},
+		{name: "Per commit with an output", target: reviewTarget{perCommit: true, output: "review.txt"}, format: "text", wantErr: "-format and -output cannot be combined with -per-commit"},
+		{name: "Incremental per commit", target: reviewTarget{incremental: true, perCommit: true}, format: "text", wantErr: "-incremental can only be used"},
+		{name: "Unknown format", format: "html", wantErr: "Invalid -format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := tt.target.check(tt.format)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFormat, format)
+		})
+	}
+}
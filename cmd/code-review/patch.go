@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/lmquang/code-review/pkg/diff"
+	"github.com/lmquang/code-review/pkg/git"
+)
+
+// patchPrefixRegex matches the '[PATCH v2 1/3]' prefix git format-patch adds to the subjects
+var patchPrefixRegex = regexp.MustCompile(`^\[[^\]]*\]\s*`)
+
+// readPatch reads the patch to review from a file, or from stdin when the path is '-'
+func readPatch(path string) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the patch from stdin: %v", err)
+		}
+		return string(data), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the patch: %v", err)
+	}
+	return string(data), nil
+}
+
+// patchBaseRevision resolves the revision the original content of a patch is read at, which is
+// the commit the patch applies to. It returns an empty revision, leaving the original content
+// out, when there is no repository or the revision is not in it.
+func patchBaseRevision(gitClient git.IGit, base string) string {
+	if gitClient == nil {
+		return ""
+	}
+	if _, err := gitClient.GetGitDir(); err != nil {
+		log.Printf("Warning: not in a git repository, the patch is reviewed without the original content of the files")
+		return ""
+	}
+	_, revision, err := gitClient.ResolveCommit(base)
+	if err != nil {
+		log.Printf("Warning: the patch is reviewed without the original content of the files: %v", err)
+		return ""
+	}
+	return revision
+}
+
+// checkPatch checks that a patch is in the format of git, which is the only one understood.
+// Patches in another format are not empty but change no file. A series of patches from git
+// format-patch may not change a file more than once, as the original content is only read
+// before the first patch and the line numbers of later patches do not apply to it.
+func checkPatch(rawDiff string) error {
+	if strings.TrimSpace(rawDiff) == "" {
+		return nil
+	}
+	fileDiffs, err := diff.Parse(rawDiff)
+	if err != nil {
+		return err
+	}
+	if len(fileDiffs) == 0 {
+		return fmt.Errorf("no changed files found, the patch must be produced by 'git diff' or 'git format-patch'")
+	}
+
+	changed := make(map[string]bool, len(fileDiffs))
+	for _, fileDiff := range fileDiffs {
+		if changed[fileDiff.Path()] {
+			return fmt.Errorf("%s is changed by more than one patch of the series, review the combined change produced by 'git diff' instead", fileDiff.Path())
+		}
+		changed[fileDiff.Path()] = true
+	}
+	return nil
+}
+
+// patchMessages extracts the commit messages of the emails produced by git format-patch: the
+// subject without its '[PATCH]' prefix, followed by the body up to the '---' line before the diff.
+// Plain diffs have no messages.
+func patchMessages(rawDiff string) []string {
+	var messages []string
+	var message []string
+	inHeaders, inBody := false, false
+	for _, line := range strings.Split(rawDiff, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "From ") && !inBody:
+			inHeaders, message = true, nil
+		case inHeaders && line == "":
+			inHeaders, inBody = false, true
+		case inHeaders && strings.HasPrefix(line, "Subject: "):
+			message = append(message, patchPrefixRegex.ReplaceAllString(strings.TrimPrefix(line, "Subject: "), ""))
+		case inHeaders && len(message) == 1 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			// Long subjects are folded over several header lines
+			message[0] += " " + strings.TrimSpace(line)
+		case inBody && (line == "---" || strings.HasPrefix(line, "diff --git ")):
+			inBody = false
+			if text := strings.TrimSpace(strings.Join(message, "\n")); text != "" {
+				messages = append(messages, text)
+			}
+		case inBody:
+			if len(message) == 1 {
+				message = append(message, "")
+			}
+			message = append(message, line)
+		}
+	}
+	return messages
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// email is a patch in the format of git format-patch, changing one line of a file
+func email(subject, body, file, before, after string) string {
+	return "From 1234567890abcdef1234567890abcdef12345678 Mon Sep 17 00:00:00 2001\n" +
+		"From: Test <test@example.com>\n" +
+		"Date: Mon, 1 Jan 2024 00:00:00 +0000\n" +
+		"Subject: " + subject + "\n" +
+		"\n" +
+		body +
+		"---\n" +
+		" " + file + " | 2 +-\n" +
+		" 1 file changed, 1 insertion(+), 1 deletion(-)\n" +
+		"\n" +
+		fileDiff(file, before, after) +
+		"-- \n" +
+		"2.43.0\n" +
+		"\n"
+}
+
+// fileDiff is the diff of a file changing its first line
+func fileDiff(file, before, after string) string {
+	return "diff --git a/" + file + " b/" + file + "\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/" + file + "\n" +
+		"+++ b/" + file + "\n" +
+		"@@ -1 +1 @@\n" +
+		"-" + before + "\n" +
+		"+" + after + "\n"
+}
+
+func TestCheckPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		wantErr string
+	}{
+		{
+			name:  "Empty patch",
+			patch: "\n",
+		},
+		{
+			name:  "Plain diff",
+			patch: fileDiff("a.go", "old", "new") + fileDiff("b.go", "old", "new"),
+		},
+		{
+			name:  "Series changing different files",
+			patch: email("[PATCH 1/2] Change a", "", "a.go", "old", "new") + email("[PATCH 2/2] Change b", "", "b.go", "old", "new"),
+		},
+		{
+			name:    "Series changing a file twice",
+			patch:   email("[PATCH 1/2] Change a", "", "a.go", "old", "new") + email("[PATCH 2/2] Change a again", "", "a.go", "new", "newer"),
+			wantErr: "a.go is changed by more than one patch of the series",
+		},
+		{
+			name:    "Not a git patch",
+			patch:   "--- a.go\n+++ a.go\n@@ -1 +1 @@\n-old\n+new\n",
+			wantErr: "no changed files found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPatch(tt.patch)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPatchMessages(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  []string
+	}{
+		{
+			name:  "Plain diff",
+			patch: fileDiff("a.go", "old", "new"),
+			want:  nil,
+		},
+		{
+			name:  "Subject only",
+			patch: email("[PATCH] Fix the total", "", "a.go", "old", "new"),
+			want:  []string{"Fix the total"},
+		},
+		{
+			name:  "Subject and body",
+			patch: email("[PATCH v2] Fix the total", "The discount was applied twice.\n\nSigned-off-by: Test\n", "a.go", "old", "new"),
+			want:  []string{"Fix the total\n\nThe discount was applied twice.\n\nSigned-off-by: Test"},
+		},
+		{
+			name:  "Folded subject",
+			patch: email("[PATCH] Fix the total when a discount and\n a tax are both applied", "", "a.go", "old", "new"),
+			want:  []string{"Fix the total when a discount and a tax are both applied"},
+		},
+		{
+			name:  "Series of emails",
+			patch: email("[PATCH 1/2] Change a", "First body.\n", "a.go", "old", "new") + email("[PATCH 2/2] Change b", "", "b.go", "old", "new"),
+			want:  []string{"Change a\n\nFirst body.", "Change b"},
+		},
+		{
+			name:  "Body lines starting with From are not a new email",
+			patch: email("[PATCH] Change a", "From now on the total is rounded.\n", "a.go", "old", "new"),
+			want:  []string{"Change a\n\nFrom now on the total is rounded."},
+		},
+		{
+			name:  "Windows line endings",
+			patch: "From 1234567890abcdef1234567890abcdef12345678 Mon Sep 17 00:00:00 2001\r\nSubject: [PATCH] Change a\r\n\r\nBody.\r\n---\r\n" + fileDiff("a.go", "old", "new"),
+			want:  []string{"Change a\n\nBody."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, patchMessages(tt.patch))
+		})
+	}
+}
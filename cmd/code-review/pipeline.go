@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/lmquang/code-review/pkg/diff"
+	"github.com/lmquang/code-review/pkg/git"
+	"github.com/lmquang/code-review/pkg/gpt"
+	"github.com/lmquang/code-review/pkg/review"
+)
+
+// defaultConcurrency is the default number of review requests in flight
+const defaultConcurrency = 4
+
+// reviewer formats changes, splits them into batches within the token budget and sends them to GPT for review
+type reviewer struct {
+	gitClient     git.IGit
+	diffFormatter diff.IDiff
+	gptClient     gpt.IGPT
+	// tokenBudget is the maximum estimated number of prompt tokens of a single request
+	tokenBudget int
+	// split selects how files are grouped into requests
+	split diff.SplitStrategy
+	// concurrency is the maximum number of requests in flight
+	concurrency int
+	// stream prints the final answer of each review as it is generated
+	stream bool
+	// format and output select how the final review is reported, and report is where it is
+	// written when there is no output file
+	format review.Format
+	output string
+	report io.Writer
+	// progress receives the progress messages and the review as it is generated, kept apart
+	// from a structured report written to stdout
+	progress io.Writer
+	// failOn is the severity of findings that fail the review, empty to never fail
+	failOn review.Severity
+}
+
+// reviewPrinter prints a review to the progress output under a header. When streaming, the
+// header is printed with the first piece of the answer, so that nothing is printed for failed requests.
+type reviewPrinter struct {
+	out     io.Writer
+	header  string
+	stream  bool
+	started bool
+}
+
+// printer creates a printer for a review under the given header
+func (r *reviewer) printer(header string) *reviewPrinter {
+	return &reviewPrinter{out: r.progress, header: header, stream: r.stream}
+}
+
+func (p *reviewPrinter) Write(b []byte) (int, error) {
+	if !p.started {
+		p.started = true
+		fmt.Fprint(p.out, p.header)
+	}
+	return p.out.Write(b)
+}
+
+// options returns the review options streaming the answer to the printer when streaming is enabled
+func (p *reviewPrinter) options(opts gpt.ReviewOptions) gpt.ReviewOptions {
+	if p.stream {
+		opts.Stream = p
+	}
+	return opts
+}
+
+// print prints the complete review under the header, or only ends it when it was streamed
+func (p *reviewPrinter) print(review string) {
+	if p.started {
+		fmt.Fprintln(p.out)
+		return
+	}
+	fmt.Fprint(p.out, p.header)
+	fmt.Fprintln(p.out, review)
+}
+
+// reviewCommits reviews every commit between two revisions on its own and prints a report grouped by commit.
+// Merge commits are skipped, as their changes are those of the commits they merge.
+func (r *reviewer) reviewCommits(ctx context.Context, from, to string) {
+	all, err := r.gitClient.GetCommits(from, to)
+	if err != nil {
+		fatalf("Error getting commits: %v", err)
+	}
+	var commits []git.Commit
+	for _, commit := range all {
+		if !commit.Merge {
+			commits = append(commits, commit)
+		}
+	}
+	if merges := len(all) - len(commits); merges > 0 {
+		fmt.Fprintf(r.progress, "Skipping %d merge commit(s)\n", merges)
+	}
+	if len(commits) == 0 {
+		fmt.Fprintln(r.progress, "No commits to review.")
+		return
+	}
+
+	var report strings.Builder
+	failing := 0
+	for i, commit := range commits {
+		subject, _, _ := strings.Cut(commit.Message, "\n")
+		fmt.Fprintf(r.progress, "Reviewing commit %d/%d %s %s\n", i+1, len(commits), shortHash(commit.Hash), subject)
+
+		parent, hash, err := r.gitClient.ResolveCommit(commit.Hash)
+		if err != nil {
+			fatalf("Error resolving commit %s: %v", commit.Hash, err)
+		}
+
+		rawDiff, err := r.gitClient.GetRangeDiff(parent, hash)
+		if err != nil {
+			fatalf("Error getting git diff for commit %s: %v", commit.Hash, err)
+		}
+
+		header := fmt.Sprintf("=== Commit %s: %s ===\n", shortHash(commit.Hash), subject)
+		printer := r.printer(header)
+		gptResponse := "No changes to review."
+		if rawDiff != "" {
+			var files []diff.FormattedFile
+			gptResponse, files, err = r.reviewDiff(ctx, rawDiff, parent, printer.options(gpt.ReviewOptions{
+				CommitMessages: []string{commit.Message},
+			}))
+			if err != nil {
+				fatalf("Error sending commit %s to GPT: %v", commit.Hash, err)
+			}
+			if gptResponse == "" {
+				gptResponse = "No changes to review after applying ignore patterns."
+			} else if r.failOn != "" {
+				failing += r.structured(ctx, gptResponse, hunksByPath(files)).CountAtLeast(r.failOn)
+			}
+		}
+
+		// Streamed reviews are printed as they arrive rather than in a final report
+		if r.stream {
+			printer.print(gptResponse)
+			fmt.Fprintln(r.progress)
+			continue
+		}
+		report.WriteString(header)
+		report.WriteString(gptResponse)
+		report.WriteString("\n\n")
+	}
+
+	if !r.stream {
+		fmt.Fprintln(r.progress, "GPT Review:")
+		fmt.Fprint(r.progress, report.String())
+	}
+	r.checkFindings(failing)
+}
+
+// reviewDiff formats a diff and sends it to GPT for review. Changes larger than the token
+// budget are reviewed in batches of files whose reviews are then merged into one. It returns
+// an empty response when no files are left to review after applying the ignore patterns,
+// and the reviewed files. Only the final answer is streamed, as it is
+// the only request in flight.
+func (r *reviewer) reviewDiff(ctx context.Context, rawDiff, baseRevision string, opts gpt.ReviewOptions) (string, []diff.FormattedFile, error) {
+	files, errors := r.diffFormatter.FormatFiles(rawDiff, baseRevision)
+	if len(errors) > 0 {
+		fmt.Fprintln(r.progress, "Encountered errors while processing some files:")
+		for _, err := range errors {
+			fmt.Fprintf(r.progress, "- %v\n", err)
+		}
+		fmt.Fprintln(r.progress, "Continuing with the files that were processed successfully.")
+	}
+
+	if len(files) == 0 {
+		return "", files, nil
+	}
+
+	budget := r.tokenBudget - gpt.ReviewPromptTokens(opts)
+	batches := diff.Split(files, r.split, budget, gpt.EstimateTokens)
+	if len(batches) == 1 {
+		originalContent, formattedDiff := diff.JoinFiles(batches[0])
+		review, err := r.gptClient.Review(ctx, originalContent, formattedDiff, opts)
+		return review, files, err
+	}
+
+	fmt.Fprintf(r.progress, "Reviewing %d files in %d batches\n", len(files), len(batches))
+	batchOpts := opts
+	batchOpts.Stream = nil
+	reviews := make([]string, len(batches))
+	err := forEach(len(batches), r.concurrency, func(i int) error {
+		originalContent, formattedDiff := diff.JoinFiles(batches[i])
+		fmt.Fprintf(r.progress, "Reviewing batch %d/%d (%d files)\n", i+1, len(batches), len(batches[i]))
+		if tokens := gpt.EstimateTokens(originalContent) + gpt.EstimateTokens(formattedDiff); tokens > budget {
+			fmt.Fprintf(r.progress, "Warning: %s alone exceeds the token budget (about %d tokens)\n", batches[i][0].Path, tokens)
+		}
+
+		review, err := r.gptClient.Review(ctx, originalContent, formattedDiff, batchOpts)
+		if err != nil {
+			return fmt.Errorf("failed to review batch %d: %v", i+1, err)
+		}
+		reviews[i] = review
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	review, err := r.mergeReviews(ctx, reviews, opts)
+	return review, files, err
+}
+
+// hunksByPath returns the hunks of reviewed files by path, or nil when there are no files
+func hunksByPath(files []diff.FormattedFile) map[string][]diff.Hunk {
+	if files == nil {
+		return nil
+	}
+	// Hunks are appended, so that a file listed twice keeps the hunks of both entries
+	hunks := make(map[string][]diff.Hunk, len(files))
+	for _, file := range files {
+		hunks[file.Path] = append(hunks[file.Path], file.Hunks...)
+	}
+	return hunks
+}
+
+// mergeReviews merges the reviews of several batches into one. When the reviews do not fit
+// in the token budget together, groups of reviews are merged first, level by level. Only the
+// final merge is streamed.
+func (r *reviewer) mergeReviews(ctx context.Context, reviews []string, opts gpt.ReviewOptions) (string, error) {
+	budget := r.tokenBudget - gpt.MergePromptTokens(opts)
+	groupOpts := opts
+	groupOpts.Stream = nil
+	for {
+		groups := groupReviews(reviews, budget)
+		if len(groups) == 1 || len(groups) == len(reviews) {
+			fmt.Fprintf(r.progress, "Merging %d reviews\n", len(reviews))
+			return r.gptClient.Merge(ctx, reviews, opts)
+		}
+
+		fmt.Fprintf(r.progress, "Merging %d reviews in %d groups\n", len(reviews), len(groups))
+		merged := make([]string, len(groups))
+		err := forEach(len(groups), r.concurrency, func(i int) error {
+			if len(groups[i]) == 1 {
+				merged[i] = groups[i][0]
+				return nil
+			}
+			review, err := r.gptClient.Merge(ctx, groups[i], groupOpts)
+			if err != nil {
+				return fmt.Errorf("failed to merge reviews: %v", err)
+			}
+			merged[i] = review
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		reviews = merged
+	}
+}
+
+// groupReviews splits reviews into consecutive groups whose estimated size stays within the budget
+func groupReviews(reviews []string, budget int) [][]string {
+	var groups [][]string
+	var current []string
+	size := 0
+	for _, review := range reviews {
+		tokens := gpt.EstimateTokens(review)
+		if len(current) > 0 && size+tokens > budget {
+			groups = append(groups, current)
+			current = nil
+			size = 0
+		}
+		current = append(current, review)
+		size += tokens
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// forEach calls fn for every index below n with at most limit calls running concurrently.
+// It waits for all calls and returns the error of the lowest failing index, so that the
+// outcome does not depend on scheduling.
+func forEach(n, limit int, fn func(i int) error) error {
+	errs := make([]error, n)
+	semaphore := make(chan struct{}, max(limit, 1))
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shortHash abbreviates a commit hash for display
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	mocksdiff "github.com/lmquang/code-review/mocks/pkg/diff"
+	mocksgit "github.com/lmquang/code-review/mocks/pkg/git"
+	mocksgpt "github.com/lmquang/code-review/mocks/pkg/gpt"
+	"github.com/lmquang/code-review/pkg/diff"
+	"github.com/lmquang/code-review/pkg/git"
+	"github.com/lmquang/code-review/pkg/gpt"
+)
+
+// newTestReviewer creates a reviewer with mocked clients, writing its progress to a buffer
+func newTestReviewer(t *testing.T) (*reviewer, *mocksgit.IGit, *mocksdiff.IDiff, *mocksgpt.IGPT, *bytes.Buffer) {
+	gitClient := mocksgit.NewIGit(t)
+	diffFormatter := mocksdiff.NewIDiff(t)
+	gptClient := mocksgpt.NewIGPT(t)
+	var progress bytes.Buffer
+	r := &reviewer{
+		gitClient:     gitClient,
+		diffFormatter: diffFormatter,
+		gptClient:     gptClient,
+		tokenBudget:   100000,
+		split:         diff.SplitBudget,
+		concurrency:   1,
+		progress:      &progress,
+	}
+	return r, gitClient, diffFormatter, gptClient, &progress
+}
+
+// expectCommit sets up the review of a commit whose diff changes a single file
+func expectCommit(gitClient *mocksgit.IGit, diffFormatter *mocksdiff.IDiff, gptClient *mocksgpt.IGPT, commit git.Commit, parent, file, answer string) {
+	gitClient.On("ResolveCommit", commit.Hash).Return(parent, commit.Hash, nil)
+	rawDiff := "diff of " + commit.Hash
+	gitClient.On("GetRangeDiff", parent, commit.Hash).Return(rawDiff, nil)
+	diffFormatter.On("FormatFiles", rawDiff, parent).Return([]diff.FormattedFile{{Path: file, Diff: "<file>" + file + "</file>"}}, nil)
+	gptClient.On("Review", mock.Anything, mock.Anything, mock.Anything, gpt.ReviewOptions{CommitMessages: []string{commit.Message}}).Return(answer, nil)
+}
+
+func TestReviewCommits(t *testing.T) {
+	t.Run("Reviews each commit with its own message, in order", func(t *testing.T) {
+		r, gitClient, diffFormatter, gptClient, progress := newTestReviewer(t)
+		first := git.Commit{Hash: "1111111aaaa", Message: "Add a\n\nWith a body."}
+		second := git.Commit{Hash: "2222222bbbb", Message: "Change b"}
+		gitClient.On("GetCommits", "base", "HEAD").Return([]git.Commit{first, second}, nil)
+		expectCommit(gitClient, diffFormatter, gptClient, first, "base", "a.go", "Review of a")
+		expectCommit(gitClient, diffFormatter, gptClient, second, first.Hash, "b.go", "Review of b")
+
+		r.reviewCommits(context.Background(), "base", "HEAD")
+
+		assert.Equal(t, "Reviewing commit 1/2 1111111 Add a\n"+
+			"Reviewing commit 2/2 2222222 Change b\n"+
+			"GPT Review:\n"+
+			"=== Commit 1111111: Add a ===\nReview of a\n\n"+
+			"=== Commit 2222222: Change b ===\nReview of b\n\n", progress.String())
+	})
+
+	t.Run("Skips merge commits and does not review empty commits", func(t *testing.T) {
+		r, gitClient, diffFormatter, gptClient, progress := newTestReviewer(t)
+		change := git.Commit{Hash: "1111111aaaa", Message: "Change a"}
+		merge := git.Commit{Hash: "2222222bbbb", Message: "Merge branch 'side'", Merge: true}
+		empty := git.Commit{Hash: "3333333cccc", Message: "Empty"}
+		gitClient.On("GetCommits", "base", "HEAD").Return([]git.Commit{change, merge, empty}, nil)
+		expectCommit(gitClient, diffFormatter, gptClient, change, "base", "a.go", "Review of a")
+		gitClient.On("ResolveCommit", empty.Hash).Return(merge.Hash, empty.Hash, nil)
+		gitClient.On("GetRangeDiff", merge.Hash, empty.Hash).Return("", nil)
+
+		r.reviewCommits(context.Background(), "base", "HEAD")
+
+		assert.Equal(t, "Skipping 1 merge commit(s)\n"+
+			"Reviewing commit 1/2 1111111 Change a\n"+
+			"Reviewing commit 2/2 3333333 Empty\n"+
+			"GPT Review:\n"+
+			"=== Commit 1111111: Change a ===\nReview of a\n\n"+
+			"=== Commit 3333333: Empty ===\nNo changes to review.\n\n", progress.String())
+	})
+
+	t.Run("Only merge commits", func(t *testing.T) {
+		r, gitClient, _, _, progress := newTestReviewer(t)
+		gitClient.On("GetCommits", "base", "HEAD").Return([]git.Commit{{Hash: "2222222bbbb", Message: "Merge", Merge: true}}, nil)
+
+		r.reviewCommits(context.Background(), "base", "HEAD")
+
+		assert.Equal(t, "Skipping 1 merge commit(s)\nNo commits to review.\n", progress.String())
+	})
+}
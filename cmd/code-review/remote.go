@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/lmquang/code-review/pkg/diff"
+	"github.com/lmquang/code-review/pkg/git"
+	"github.com/lmquang/code-review/pkg/gpt"
+	"github.com/lmquang/code-review/pkg/review"
+)
+
+// reviewRemote reviews the diff of a pull or merge request fetched from its hosting platform,
+// with its title and description as context, and parses the review. The original content is
+// read from the local clone, which has the base commit when it is checked out with enough
+// history. It returns the reviewed files, and false when there is nothing to review.
+func (r *reviewer) reviewRemote(ctx context.Context, rawDiff, baseSHA, headSHA, title, description string) (review.Review, []diff.FormattedFile, bool) {
+	if rawDiff == "" {
+		fmt.Fprintln(r.progress, "No changes detected.")
+		return review.Review{}, nil, false
+	}
+
+	baseRevision, err := r.gitClient.GetBaseRevision(git.DiffOptions{Base: baseSHA, Head: headSHA})
+	if err != nil {
+		log.Printf("Warning: the merge base of the changes was not found locally, reading the original content at their base: %v", err)
+		baseRevision = baseSHA
+	}
+
+	printer := r.printer("GPT Review:\n")
+	reviewOptions := gpt.ReviewOptions{CommitMessages: []string{strings.TrimSpace(title + "\n\n" + description)}}
+	gptResponse, files, err := r.reviewDiff(ctx, rawDiff, baseRevision, printer.options(reviewOptions))
+	if err != nil {
+		fatalf("Error sending to GPT: %v", err)
+	}
+	if gptResponse == "" {
+		fmt.Fprintln(r.progress, "No changes to review after applying ignore patterns.")
+		return review.Review{}, nil, false
+	}
+	printer.print(gptResponse)
+
+	return r.structured(ctx, gptResponse, hunksByPath(files)), files, true
+}
+
+// firstNonEmpty returns the first of the values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
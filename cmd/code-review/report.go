@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/lmquang/code-review/pkg/diff"
+	"github.com/lmquang/code-review/pkg/review"
+)
+
+// maxRepairs is the number of times a review that cannot be parsed is sent back for repair
+const maxRepairs = 2
+
+// finish reports the final review and exits with exitFindings when it has findings of the
+// -fail-on severity or higher. An empty answer is reported as a review without findings.
+// The findings are checked against the hunks of the reviewed files unless they are nil.
+func (r *reviewer) finish(ctx context.Context, answer string, hunks map[string][]diff.Hunk) {
+	var parsed review.Review
+	if answer != "" && (r.format != review.FormatText || r.failOn != "") {
+		parsed = r.structured(ctx, answer, hunks)
+	}
+
+	if err := r.writeReport(answer, parsed); err != nil {
+		fatalf("Error writing the review: %v", err)
+	}
+	if r.failOn != "" {
+		r.checkFindings(parsed.CountAtLeast(r.failOn))
+	}
+}
+
+// checkFindings exits with exitFindings when there are findings of the -fail-on severity or higher
+func (r *reviewer) checkFindings(count int) {
+	if count > 0 {
+		log.Printf("Found %d finding(s) of %s severity or higher", count, r.failOn)
+		os.Exit(exitFindings)
+	}
+}
+
+// writeReport writes the final review in the selected format, to the output file when one is
+// given and to stdout otherwise. Reviews in the text format are only written to a file, as
+// they are already printed.
+func (r *reviewer) writeReport(answer string, parsed review.Review) error {
+	if r.format == review.FormatText && r.output == "" {
+		return nil
+	}
+
+	var report bytes.Buffer
+	if r.format == review.FormatText {
+		if answer != "" {
+			report.WriteString(answer + "\n")
+		}
+	} else if err := review.Encode(&report, r.format, parsed); err != nil {
+		return fmt.Errorf("failed to encode the review: %v", err)
+	}
+
+	if r.output == "" {
+		_, err := r.report.Write(report.Bytes())
+		return err
+	}
+	if err := os.WriteFile(r.output, report.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write the review: %v", err)
+	}
+	fmt.Fprintf(r.progress, "Review written to %s\n", r.output)
+	return nil
+}
+
+// structured parses a review and moves its findings onto the changed lines, dropping those
+// outside the changes unless the hunks are nil
+func (r *reviewer) structured(ctx context.Context, answer string, hunks map[string][]diff.Hunk) review.Review {
+	parsed, err := r.parseReview(ctx, answer)
+	if err != nil {
+		fatalf("Error parsing the review: %v", err)
+	}
+	if hunks == nil {
+		return parsed
+	}
+
+	parsed, dropped := review.Snap(parsed, hunks)
+	for _, finding := range dropped {
+		log.Printf("Warning: dropped a finding on %s line %d, outside the changes: %s", finding.File, finding.StartLine, finding.Message)
+	}
+	return parsed
+}
+
+// parseReview parses a review, sending it back to GPT for repair while it is malformed
+func (r *reviewer) parseReview(ctx context.Context, answer string) (review.Review, error) {
+	for repairs := 0; ; repairs++ {
+		parsed, err := review.Parse(answer)
+		if err == nil {
+			return parsed, nil
+		}
+		if repairs == maxRepairs {
+			return review.Review{}, fmt.Errorf("the review is still malformed after %d repairs: %v", maxRepairs, err)
+		}
+
+		log.Printf("The review is malformed, asking for a repair: %v", err)
+		answer, err = r.gptClient.Repair(ctx, answer, err.Error())
+		if err != nil {
+			return review.Review{}, fmt.Errorf("failed to repair the review: %v", err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package forge holds what the clients of the code hosting services have in common: calling
+// their paginated JSON REST APIs and preparing the comments of a review.
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// perPage is the number of items requested per page, the maximum GitHub and GitLab allow
+const perPage = 100
+
+// nextLinkRegex matches the URL of the next page in the Link header of a paginated response
+var nextLinkRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// Client sends JSON requests to a REST API
+type Client struct {
+	HTTPClient *http.Client
+	// Header is set on every request, for the authentication and the version of the API
+	Header http.Header
+}
+
+// List calls onPage with the body of every page of a paginated endpoint, following the Link
+// headers of the responses
+func (c *Client) List(ctx context.Context, url string, onPage func(data []byte) error) error {
+	url += fmt.Sprintf("?per_page=%d", perPage)
+	for url != "" {
+		req, err := c.NewRequest(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := c.Send(req)
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("error reading response: %v", err)
+		}
+		if err := onPage(data); err != nil {
+			return fmt.Errorf("error decoding response: %v", err)
+		}
+
+		url = ""
+		if match := nextLinkRegex.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+			url = match[1]
+		}
+	}
+	return nil
+}
+
+// Do sends a request with an optional JSON body and decodes the JSON response into out
+// unless it is nil
+func (c *Client) Do(ctx context.Context, method, url string, body, out interface{}) error {
+	req, err := c.NewRequest(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	return nil
+}
+
+// NewRequest creates a request with the headers of the client and an optional JSON body
+func (c *Client) NewRequest(ctx context.Context, method, url string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	for name, values := range c.Header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// Send sends a request and returns the response when it is successful. The message of an
+// unsuccessful response is returned as an error.
+func (c *Client) Send(req *http.Request) (*http.Response, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("status %d: error reading response: %v", resp.StatusCode, err)
+	}
+	return nil, fmt.Errorf("status %d: %s", resp.StatusCode, errorMessage(data))
+}
+
+// errorMessage extracts the message of an error response. Both services return a message,
+// which GitLab makes an object of messages by field for validation errors. GitLab may return
+// an error instead.
+func errorMessage(data []byte) string {
+	var apiError struct {
+		Message json.RawMessage `json:"message"`
+		Error   string          `json:"error"`
+	}
+	if json.Unmarshal(data, &apiError) != nil {
+		return strings.TrimSpace(string(data))
+	}
+
+	var message string
+	switch {
+	case json.Unmarshal(apiError.Message, &message) == nil && message != "":
+		return message
+	case len(apiError.Message) > 0:
+		return string(apiError.Message)
+	case apiError.Error != "":
+		return apiError.Error
+	default:
+		return strings.TrimSpace(string(data))
+	}
+}
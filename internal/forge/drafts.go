@@ -0,0 +1,19 @@
+package forge
+
+// MergeDrafts combines the drafts on the same line, as given by line, into one comment, as
+// several comments on a line could not be told apart by later reviews. body returns the body
+// of a draft, to which the bodies of the drafts merged into it are appended.
+func MergeDrafts[D any, K comparable](drafts []D, line func(D) K, body func(*D) *string) []D {
+	var merged []D
+	index := make(map[K]int)
+	for _, draft := range drafts {
+		key := line(draft)
+		if i, found := index[key]; found {
+			*body(&merged[i]) += "\n\n---\n\n" + *body(&draft)
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, draft)
+	}
+	return merged
+}
@@ -0,0 +1,85 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		switch r.URL.Path {
+		case "/items":
+			page := r.URL.Query().Get("page")
+			if page == "" {
+				assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+				w.Header().Set("Link", fmt.Sprintf(`<%s/items?page=2&per_page=100>; rel="next", <%s/items?page=2&per_page=100>; rel="last"`, server.URL, server.URL))
+				fmt.Fprint(w, `[1, 2]`)
+				return
+			}
+			fmt.Fprint(w, `[3]`)
+		case "/echo":
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			var body map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.NoError(t, json.NewEncoder(w).Encode(body))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"404 Not Found"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	header := http.Header{}
+	header.Set("PRIVATE-TOKEN", "secret")
+	client := &Client{HTTPClient: server.Client(), Header: header}
+	ctx := context.Background()
+
+	var items []int
+	err := client.List(ctx, server.URL+"/items", func(data []byte) error {
+		var page []int
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		items = append(items, page...)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, items)
+
+	var out map[string]string
+	assert.NoError(t, client.Do(ctx, http.MethodPost, server.URL+"/echo", map[string]string{"body": "hello"}, &out))
+	assert.Equal(t, map[string]string{"body": "hello"}, out)
+
+	err = client.Do(ctx, http.MethodGet, server.URL+"/missing", nil, nil)
+	assert.EqualError(t, err, "status 404: 404 Not Found")
+}
+
+func TestErrorMessage(t *testing.T) {
+	assert.Equal(t, "401 Unauthorized", errorMessage([]byte(`{"message":"401 Unauthorized"}`)))
+	assert.Equal(t, `{"note":["can't be blank"]}`, errorMessage([]byte(`{"message":{"note":["can't be blank"]}}`)))
+	assert.Equal(t, "insufficient_scope", errorMessage([]byte(`{"error":"insufficient_scope"}`)))
+	assert.Equal(t, "Bad Gateway", errorMessage([]byte("Bad Gateway\n")))
+}
+
+func TestMergeDrafts(t *testing.T) {
+	type draft struct {
+		line int
+		body string
+	}
+	drafts := []draft{{10, "first"}, {20, "other"}, {10, "second"}}
+
+	got := MergeDrafts(drafts,
+		func(d draft) int { return d.line },
+		func(d *draft) *string { return &d.body })
+
+	assert.Equal(t, []draft{{10, "first\n\n---\n\nsecond"}, {20, "other"}}, got)
+	assert.Equal(t, "first", drafts[0].body, "the drafts passed in are left unchanged")
+}
@@ -2,16 +2,19 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	diff "github.com/lmquang/code-review/pkg/diff"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // IDiff is an autogenerated mock type for the IDiff type
 type IDiff struct {
 	mock.Mock
 }
 
-// Format provides a mock function with given fields: _a0, changedFiles
-func (_m *IDiff) Format(_a0 string, changedFiles []string) (string, string, []error) {
-	ret := _m.Called(_a0, changedFiles)
+// Format provides a mock function with given fields: _a0, baseRevision
+func (_m *IDiff) Format(_a0 string, baseRevision string) (string, string, []error) {
+	ret := _m.Called(_a0, baseRevision)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Format")
@@ -20,23 +23,23 @@ func (_m *IDiff) Format(_a0 string, changedFiles []string) (string, string, []er
 	var r0 string
 	var r1 string
 	var r2 []error
-	if rf, ok := ret.Get(0).(func(string, []string) (string, string, []error)); ok {
-		return rf(_a0, changedFiles)
+	if rf, ok := ret.Get(0).(func(string, string) (string, string, []error)); ok {
+		return rf(_a0, baseRevision)
 	}
-	if rf, ok := ret.Get(0).(func(string, []string) string); ok {
-		r0 = rf(_a0, changedFiles)
+	if rf, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = rf(_a0, baseRevision)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, []string) string); ok {
-		r1 = rf(_a0, changedFiles)
+	if rf, ok := ret.Get(1).(func(string, string) string); ok {
+		r1 = rf(_a0, baseRevision)
 	} else {
 		r1 = ret.Get(1).(string)
 	}
 
-	if rf, ok := ret.Get(2).(func(string, []string) []error); ok {
-		r2 = rf(_a0, changedFiles)
+	if rf, ok := ret.Get(2).(func(string, string) []error); ok {
+		r2 = rf(_a0, baseRevision)
 	} else {
 		if ret.Get(2) != nil {
 			r2 = ret.Get(2).([]error)
@@ -46,6 +49,43 @@ func (_m *IDiff) Format(_a0 string, changedFiles []string) (string, string, []er
 	return r0, r1, r2
 }
 
+// FormatFiles provides a mock function with given fields: _a0, baseRevision
+func (_m *IDiff) FormatFiles(_a0 string, baseRevision string) ([]diff.FormattedFile, []error) {
+	ret := _m.Called(_a0, baseRevision)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FormatFiles")
+	}
+
+	var r0 []diff.FormattedFile
+	var r1 []error
+	if rf, ok := ret.Get(0).(func(string, string) ([]diff.FormattedFile, []error)); ok {
+		return rf(_a0, baseRevision)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []diff.FormattedFile); ok {
+		r0 = rf(_a0, baseRevision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]diff.FormattedFile)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) []error); ok {
+		r1 = rf(_a0, baseRevision)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]error)
+		}
+	}
+
+	return r0, r1
+}
+
+// SetContext provides a mock function with given fields: strategy, lines
+func (_m *IDiff) SetContext(strategy diff.ContextStrategy, lines int) {
+	_m.Called(strategy, lines)
+}
+
 // NewIDiff creates a new instance of IDiff. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewIDiff(t interface {
@@ -2,7 +2,10 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	git "github.com/lmquang/code-review/pkg/git"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // IGit is an autogenerated mock type for the IGit type
 type IGit struct {
@@ -44,18 +47,75 @@ func (_m *IGit) ExecCommand(name string, args ...string) (string, error) {
 	return r0, r1
 }
 
-// GetDiff provides a mock function with given fields:
-func (_m *IGit) GetDiff() (string, []string, error) {
+// GetBaseRevision provides a mock function with given fields: opts
+func (_m *IGit) GetBaseRevision(opts git.DiffOptions) (string, error) {
+	ret := _m.Called(opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBaseRevision")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(git.DiffOptions) (string, error)); ok {
+		return rf(opts)
+	}
+	if rf, ok := ret.Get(0).(func(git.DiffOptions) string); ok {
+		r0 = rf(opts)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(git.DiffOptions) error); ok {
+		r1 = rf(opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCommits provides a mock function with given fields: from, to
+func (_m *IGit) GetCommits(from string, to string) ([]git.Commit, error) {
+	ret := _m.Called(from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCommits")
+	}
+
+	var r0 []git.Commit
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]git.Commit, error)); ok {
+		return rf(from, to)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []git.Commit); ok {
+		r0 = rf(from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]git.Commit)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCurrentBranch provides a mock function with given fields:
+func (_m *IGit) GetCurrentBranch() (string, error) {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetDiff")
+		panic("no return value specified for GetCurrentBranch")
 	}
 
 	var r0 string
-	var r1 []string
-	var r2 error
-	if rf, ok := ret.Get(0).(func() (string, []string, error)); ok {
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
 		return rf()
 	}
 	if rf, ok := ret.Get(0).(func() string); ok {
@@ -64,21 +124,69 @@ func (_m *IGit) GetDiff() (string, []string, error) {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func() []string); ok {
+	if rf, ok := ret.Get(1).(func() error); ok {
 		r1 = rf()
 	} else {
-		if ret.Get(1) != nil {
-			r1 = ret.Get(1).([]string)
-		}
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDefaultBranch provides a mock function with given fields:
+func (_m *IGit) GetDefaultBranch() (string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDefaultBranch")
 	}
 
-	if rf, ok := ret.Get(2).(func() error); ok {
-		r2 = rf()
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
 	} else {
-		r2 = ret.Error(2)
+		r0 = ret.Get(0).(string)
 	}
 
-	return r0, r1, r2
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDiff provides a mock function with given fields: opts
+func (_m *IGit) GetDiff(opts git.DiffOptions) (string, error) {
+	ret := _m.Called(opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDiff")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(git.DiffOptions) (string, error)); ok {
+		return rf(opts)
+	}
+	if rf, ok := ret.Get(0).(func(git.DiffOptions) string); ok {
+		r0 = rf(opts)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(git.DiffOptions) error); ok {
+		r1 = rf(opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // GetFileContentAtBranchPoint provides a mock function with given fields: file, branchPoint
@@ -109,6 +217,162 @@ func (_m *IGit) GetFileContentAtBranchPoint(file string, branchPoint string) (st
 	return r0, r1
 }
 
+// GetGitDir provides a mock function with given fields:
+func (_m *IGit) GetGitDir() (string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGitDir")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRangeDiff provides a mock function with given fields: from, to
+func (_m *IGit) GetRangeDiff(from string, to string) (string, error) {
+	ret := _m.Called(from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRangeDiff")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (string, error)); ok {
+		return rf(from, to)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = rf(from, to)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListFiles provides a mock function with given fields: dir, revision
+func (_m *IGit) ListFiles(dir string, revision string) ([]string, error) {
+	ret := _m.Called(dir, revision)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListFiles")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]string, error)); ok {
+		return rf(dir, revision)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []string); ok {
+		r0 = rf(dir, revision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(dir, revision)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResolveCommit provides a mock function with given fields: commit
+func (_m *IGit) ResolveCommit(commit string) (string, string, error) {
+	ret := _m.Called(commit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResolveCommit")
+	}
+
+	var r0 string
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string) (string, string, error)); ok {
+		return rf(commit)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(commit)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) string); ok {
+		r1 = rf(commit)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(commit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ResolveRange provides a mock function with given fields: spec
+func (_m *IGit) ResolveRange(spec string) (string, string, error) {
+	ret := _m.Called(spec)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResolveRange")
+	}
+
+	var r0 string
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string) (string, string, error)); ok {
+		return rf(spec)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(spec)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) string); ok {
+		r1 = rf(spec)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(spec)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // NewIGit creates a new instance of IGit. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewIGit(t interface {
@@ -0,0 +1,235 @@
+// Code generated by mockery v2.46.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	github "github.com/lmquang/code-review/pkg/github"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IGitHub is an autogenerated mock type for the IGitHub type
+type IGitHub struct {
+	mock.Mock
+}
+
+// CreateIssueComment provides a mock function with given fields: ctx, number, body
+func (_m *IGitHub) CreateIssueComment(ctx context.Context, number int, body string) error {
+	ret := _m.Called(ctx, number, body)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateIssueComment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, number, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateReview provides a mock function with given fields: ctx, number, commitID, comments
+func (_m *IGitHub) CreateReview(ctx context.Context, number int, commitID string, comments []github.DraftComment) error {
+	ret := _m.Called(ctx, number, commitID, comments)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReview")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, []github.DraftComment) error); ok {
+		r0 = rf(ctx, number, commitID, comments)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteReviewComment provides a mock function with given fields: ctx, id
+func (_m *IGitHub) DeleteReviewComment(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteReviewComment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetDiff provides a mock function with given fields: ctx, number
+func (_m *IGitHub) GetDiff(ctx context.Context, number int) (string, error) {
+	ret := _m.Called(ctx, number)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDiff")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (string, error)); ok {
+		return rf(ctx, number)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) string); ok {
+		r0 = rf(ctx, number)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, number)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPullRequest provides a mock function with given fields: ctx, number
+func (_m *IGitHub) GetPullRequest(ctx context.Context, number int) (github.PullRequest, error) {
+	ret := _m.Called(ctx, number)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPullRequest")
+	}
+
+	var r0 github.PullRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (github.PullRequest, error)); ok {
+		return rf(ctx, number)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) github.PullRequest); ok {
+		r0 = rf(ctx, number)
+	} else {
+		r0 = ret.Get(0).(github.PullRequest)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, number)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListIssueComments provides a mock function with given fields: ctx, number
+func (_m *IGitHub) ListIssueComments(ctx context.Context, number int) ([]github.Comment, error) {
+	ret := _m.Called(ctx, number)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListIssueComments")
+	}
+
+	var r0 []github.Comment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]github.Comment, error)); ok {
+		return rf(ctx, number)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []github.Comment); ok {
+		r0 = rf(ctx, number)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]github.Comment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, number)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListReviewComments provides a mock function with given fields: ctx, number
+func (_m *IGitHub) ListReviewComments(ctx context.Context, number int) ([]github.ReviewComment, error) {
+	ret := _m.Called(ctx, number)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListReviewComments")
+	}
+
+	var r0 []github.ReviewComment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]github.ReviewComment, error)); ok {
+		return rf(ctx, number)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []github.ReviewComment); ok {
+		r0 = rf(ctx, number)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]github.ReviewComment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, number)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateIssueComment provides a mock function with given fields: ctx, id, body
+func (_m *IGitHub) UpdateIssueComment(ctx context.Context, id int64, body string) error {
+	ret := _m.Called(ctx, id, body)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateIssueComment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, id, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateReviewComment provides a mock function with given fields: ctx, id, body
+func (_m *IGitHub) UpdateReviewComment(ctx context.Context, id int64, body string) error {
+	ret := _m.Called(ctx, id, body)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateReviewComment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, id, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIGitHub creates a new instance of IGitHub. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIGitHub(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IGitHub {
+	mock := &IGitHub{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,235 @@
+// Code generated by mockery v2.46.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	gitlab "github.com/lmquang/code-review/pkg/gitlab"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IGitLab is an autogenerated mock type for the IGitLab type
+type IGitLab struct {
+	mock.Mock
+}
+
+// CreateDiscussion provides a mock function with given fields: ctx, iid, body, position
+func (_m *IGitLab) CreateDiscussion(ctx context.Context, iid int, body string, position gitlab.Position) error {
+	ret := _m.Called(ctx, iid, body, position)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateDiscussion")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, gitlab.Position) error); ok {
+		r0 = rf(ctx, iid, body, position)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateNote provides a mock function with given fields: ctx, iid, body
+func (_m *IGitLab) CreateNote(ctx context.Context, iid int, body string) error {
+	ret := _m.Called(ctx, iid, body)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateNote")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, iid, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetDiff provides a mock function with given fields: ctx, iid
+func (_m *IGitLab) GetDiff(ctx context.Context, iid int) (string, error) {
+	ret := _m.Called(ctx, iid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDiff")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (string, error)); ok {
+		return rf(ctx, iid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) string); ok {
+		r0 = rf(ctx, iid)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, iid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMergeRequest provides a mock function with given fields: ctx, iid
+func (_m *IGitLab) GetMergeRequest(ctx context.Context, iid int) (gitlab.MergeRequest, error) {
+	ret := _m.Called(ctx, iid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMergeRequest")
+	}
+
+	var r0 gitlab.MergeRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (gitlab.MergeRequest, error)); ok {
+		return rf(ctx, iid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) gitlab.MergeRequest); ok {
+		r0 = rf(ctx, iid)
+	} else {
+		r0 = ret.Get(0).(gitlab.MergeRequest)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, iid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDiscussions provides a mock function with given fields: ctx, iid
+func (_m *IGitLab) ListDiscussions(ctx context.Context, iid int) ([]gitlab.Discussion, error) {
+	ret := _m.Called(ctx, iid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDiscussions")
+	}
+
+	var r0 []gitlab.Discussion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]gitlab.Discussion, error)); ok {
+		return rf(ctx, iid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []gitlab.Discussion); ok {
+		r0 = rf(ctx, iid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]gitlab.Discussion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, iid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListNotes provides a mock function with given fields: ctx, iid
+func (_m *IGitLab) ListNotes(ctx context.Context, iid int) ([]gitlab.Note, error) {
+	ret := _m.Called(ctx, iid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListNotes")
+	}
+
+	var r0 []gitlab.Note
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]gitlab.Note, error)); ok {
+		return rf(ctx, iid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []gitlab.Note); ok {
+		r0 = rf(ctx, iid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]gitlab.Note)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, iid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResolveDiscussion provides a mock function with given fields: ctx, iid, discussionID
+func (_m *IGitLab) ResolveDiscussion(ctx context.Context, iid int, discussionID string) error {
+	ret := _m.Called(ctx, iid, discussionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResolveDiscussion")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, iid, discussionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateDiscussionNote provides a mock function with given fields: ctx, iid, discussionID, noteID, body
+func (_m *IGitLab) UpdateDiscussionNote(ctx context.Context, iid int, discussionID string, noteID int64, body string) error {
+	ret := _m.Called(ctx, iid, discussionID, noteID, body)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateDiscussionNote")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int64, string) error); ok {
+		r0 = rf(ctx, iid, discussionID, noteID, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateNote provides a mock function with given fields: ctx, iid, id, body
+func (_m *IGitLab) UpdateNote(ctx context.Context, iid int, id int64, body string) error {
+	ret := _m.Called(ctx, iid, id, body)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateNote")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int64, string) error); ok {
+		r0 = rf(ctx, iid, id, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIGitLab creates a new instance of IGitLab. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIGitLab(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IGitLab {
+	mock := &IGitLab{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,8 +3,15 @@
 package mocks
 
 import (
-	openai "github.com/lmquang/code-review/pkg/gpt/openai"
+	context "context"
+
+	cache "github.com/lmquang/code-review/pkg/cache"
+
+	gpt "github.com/lmquang/code-review/pkg/gpt"
+
 	mock "github.com/stretchr/testify/mock"
+
+	provider "github.com/lmquang/code-review/pkg/gpt/provider"
 )
 
 // IGPT is an autogenerated mock type for the IGPT type
@@ -13,28 +20,84 @@ type IGPT struct {
 }
 
 // Client provides a mock function with given fields:
-func (_m *IGPT) Client() openai.IOpenAI {
+func (_m *IGPT) Client() provider.IProvider {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
 		panic("no return value specified for Client")
 	}
 
-	var r0 openai.IOpenAI
-	if rf, ok := ret.Get(0).(func() openai.IOpenAI); ok {
+	var r0 provider.IProvider
+	if rf, ok := ret.Get(0).(func() provider.IProvider); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(openai.IOpenAI)
+			r0 = ret.Get(0).(provider.IProvider)
 		}
 	}
 
 	return r0
 }
 
-// Review provides a mock function with given fields: originalContent, formattedDiff
-func (_m *IGPT) Review(originalContent string, formattedDiff string) (string, error) {
-	ret := _m.Called(originalContent, formattedDiff)
+// Merge provides a mock function with given fields: ctx, reviews, opts
+func (_m *IGPT) Merge(ctx context.Context, reviews []string, opts gpt.ReviewOptions) (string, error) {
+	ret := _m.Called(ctx, reviews, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Merge")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string, gpt.ReviewOptions) (string, error)); ok {
+		return rf(ctx, reviews, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, gpt.ReviewOptions) string); ok {
+		r0 = rf(ctx, reviews, opts)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string, gpt.ReviewOptions) error); ok {
+		r1 = rf(ctx, reviews, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Repair provides a mock function with given fields: ctx, review, problem
+func (_m *IGPT) Repair(ctx context.Context, review string, problem string) (string, error) {
+	ret := _m.Called(ctx, review, problem)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Repair")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, review, problem)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, review, problem)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, review, problem)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Review provides a mock function with given fields: ctx, originalContent, formattedDiff, opts
+func (_m *IGPT) Review(ctx context.Context, originalContent string, formattedDiff string, opts gpt.ReviewOptions) (string, error) {
+	ret := _m.Called(ctx, originalContent, formattedDiff, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Review")
@@ -42,17 +105,17 @@ func (_m *IGPT) Review(originalContent string, formattedDiff string) (string, er
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (string, error)); ok {
-		return rf(originalContent, formattedDiff)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, gpt.ReviewOptions) (string, error)); ok {
+		return rf(ctx, originalContent, formattedDiff, opts)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) string); ok {
-		r0 = rf(originalContent, formattedDiff)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, gpt.ReviewOptions) string); ok {
+		r0 = rf(ctx, originalContent, formattedDiff, opts)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(originalContent, formattedDiff)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, gpt.ReviewOptions) error); ok {
+		r1 = rf(ctx, originalContent, formattedDiff, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -60,6 +123,21 @@ func (_m *IGPT) Review(originalContent string, formattedDiff string) (string, er
 	return r0, r1
 }
 
+// SetCache provides a mock function with given fields: answers
+func (_m *IGPT) SetCache(answers cache.ICache) {
+	_m.Called(answers)
+}
+
+// SetGeneration provides a mock function with given fields: opts
+func (_m *IGPT) SetGeneration(opts gpt.GenerationOptions) {
+	_m.Called(opts)
+}
+
+// SetRateLimit provides a mock function with given fields: requestsPerMinute, tokensPerMinute
+func (_m *IGPT) SetRateLimit(requestsPerMinute int, tokensPerMinute int) {
+	_m.Called(requestsPerMinute, tokensPerMinute)
+}
+
 // NewIGPT creates a new instance of IGPT. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewIGPT(t interface {
@@ -0,0 +1,126 @@
+// Code generated by mockery v2.46.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	provider "github.com/lmquang/code-review/pkg/gpt/provider"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IProvider is an autogenerated mock type for the IProvider type
+type IProvider struct {
+	mock.Mock
+}
+
+// Chat provides a mock function with given fields: ctx, request
+func (_m *IProvider) Chat(ctx context.Context, request provider.ChatRequest) (provider.ChatResponse, error) {
+	ret := _m.Called(ctx, request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Chat")
+	}
+
+	var r0 provider.ChatResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, provider.ChatRequest) (provider.ChatResponse, error)); ok {
+		return rf(ctx, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, provider.ChatRequest) provider.ChatResponse); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(provider.ChatResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, provider.ChatRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChatStream provides a mock function with given fields: ctx, request, onDelta
+func (_m *IProvider) ChatStream(ctx context.Context, request provider.ChatRequest, onDelta func(string)) (provider.ChatResponse, error) {
+	ret := _m.Called(ctx, request, onDelta)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChatStream")
+	}
+
+	var r0 provider.ChatResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, provider.ChatRequest, func(string)) (provider.ChatResponse, error)); ok {
+		return rf(ctx, request, onDelta)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, provider.ChatRequest, func(string)) provider.ChatResponse); ok {
+		r0 = rf(ctx, request, onDelta)
+	} else {
+		r0 = ret.Get(0).(provider.ChatResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, provider.ChatRequest, func(string)) error); ok {
+		r1 = rf(ctx, request, onDelta)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetModel provides a mock function with given fields:
+func (_m *IProvider) GetModel() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetModel")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Name provides a mock function with given fields:
+func (_m *IProvider) Name() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Name")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// SetModel provides a mock function with given fields: model
+func (_m *IProvider) SetModel(model string) {
+	_m.Called(model)
+}
+
+// NewIProvider creates a new instance of IProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIProvider(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IProvider {
+	mock := &IProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,80 @@
+// Code generated by mockery v2.46.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	history "github.com/lmquang/code-review/pkg/history"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IHistory is an autogenerated mock type for the IHistory type
+type IHistory struct {
+	mock.Mock
+}
+
+// Last provides a mock function with given fields: branch
+func (_m *IHistory) Last(branch string) (history.Entry, bool, error) {
+	ret := _m.Called(branch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Last")
+	}
+
+	var r0 history.Entry
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string) (history.Entry, bool, error)); ok {
+		return rf(branch)
+	}
+	if rf, ok := ret.Get(0).(func(string) history.Entry); ok {
+		r0 = rf(branch)
+	} else {
+		r0 = ret.Get(0).(history.Entry)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(branch)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(branch)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Save provides a mock function with given fields: branch, entry
+func (_m *IHistory) Save(branch string, entry history.Entry) error {
+	ret := _m.Called(branch, entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, history.Entry) error); ok {
+		r0 = rf(branch, entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIHistory creates a new instance of IHistory. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIHistory(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IHistory {
+	mock := &IHistory{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
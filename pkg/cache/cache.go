@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// FileCache stores values in files named after their keys
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache creates a cache storing its entries in the given directory
+func NewFileCache(dir string) ICache {
+	return &FileCache{dir: dir}
+}
+
+// DefaultDir returns the cache directory of the tool in the user's cache directory,
+// such as ~/.cache/code-review on Linux
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting cache directory: %v", err)
+	}
+	return filepath.Join(dir, "code-review"), nil
+}
+
+// Key hashes the parts of a key into a fixed-length hex string. Each part is length-prefixed
+// so that different splits of the same text produce different keys.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(strconv.Itoa(len(part))))
+		h.Write([]byte{':'})
+		h.Write([]byte(part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the value stored for a key and whether it was found
+func (c *FileCache) Get(key string) (string, bool, error) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("error reading cache entry: %v", err)
+	}
+	return string(data), true, nil
+}
+
+// Put stores the value of a key. The entry is written to a temporary file and renamed so
+// that concurrent readers never see a partial entry.
+func (c *FileCache) Put(key, value string) error {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating cache directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error writing cache entry: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(value); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing cache entry: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing cache entry: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing cache entry: %v", err)
+	}
+	return nil
+}
+
+// Clear removes every entry of the cache
+func (c *FileCache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("error clearing cache: %v", err)
+	}
+	return nil
+}
+
+// Dir returns the directory of the cache
+func (c *FileCache) Dir() string {
+	return c.dir
+}
+
+// path returns the file of a key, sharded by the first two characters to keep directories small
+func (c *FileCache) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(c.dir, key)
+	}
+	return filepath.Join(c.dir, key[:2], key)
+}
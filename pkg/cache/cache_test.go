@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	assert.Len(t, Key("a"), 64)
+	assert.Equal(t, Key("a", "b"), Key("a", "b"))
+	assert.NotEqual(t, Key("ab", "c"), Key("a", "bc"))
+	assert.NotEqual(t, Key("a"), Key("a", ""))
+}
+
+func TestFileCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	c := NewFileCache(dir)
+	key := Key("review")
+
+	value, found, err := c.Get(key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Empty(t, value)
+
+	assert.NoError(t, c.Put(key, "<review>cached</review>"))
+	value, found, err = c.Get(key)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "<review>cached</review>", value)
+
+	assert.NoError(t, c.Put(key, "<review>updated</review>"))
+	value, _, _ = c.Get(key)
+	assert.Equal(t, "<review>updated</review>", value)
+
+	entries, err := os.ReadDir(filepath.Join(dir, key[:2]))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are removed")
+
+	assert.Equal(t, dir, c.Dir())
+	assert.NoError(t, c.Clear())
+	_, found, err = c.Get(key)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.NoError(t, c.Clear(), "clearing a missing cache succeeds")
+}
+
+func TestFileCache_Concurrent(t *testing.T) {
+	c := NewFileCache(t.TempDir())
+	key := Key("concurrent")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, c.Put(key, "value"))
+			if value, found, err := c.Get(key); found {
+				assert.NoError(t, err)
+				assert.Equal(t, "value", value)
+			}
+		}()
+	}
+	wg.Wait()
+}
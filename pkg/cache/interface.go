@@ -0,0 +1,8 @@
+package cache
+
+type ICache interface {
+	Get(key string) (string, bool, error)
+	Put(key, value string) error
+	Clear() error
+	Dir() string
+}
@@ -0,0 +1,46 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Annotate renders the hunks of a file with the number of every line in the new version of
+// the file, so that findings can refer to them without counting from the hunk headers.
+// Each line is its number, blank for deleted lines, followed by a space, its marker ('+',
+// '-' or a space) and its content.
+func Annotate(fileDiff FileDiff) string {
+	var sb strings.Builder
+	for i, hunk := range fileDiff.Hunks {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(hunkHeader(hunk))
+
+		width := len(strconv.Itoa(max(hunk.NewStart+hunk.NewLines-1, 1)))
+		for _, line := range hunk.Lines {
+			switch line.Kind {
+			case LineAdded:
+				sb.WriteString(fmt.Sprintf("\n%*d +%s", width, line.NewNumber, line.Content))
+			case LineDeleted:
+				sb.WriteString(fmt.Sprintf("\n%*s -%s", width, "", line.Content))
+			default:
+				sb.WriteString(fmt.Sprintf("\n%*d  %s", width, line.NewNumber, line.Content))
+			}
+			if line.NoNewline {
+				sb.WriteString("\n\\ No newline at end of file")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// hunkHeader renders the '@@ -old +new @@ section' header of a hunk
+func hunkHeader(hunk Hunk) string {
+	header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+	if hunk.Section != "" {
+		header += " " + hunk.Section
+	}
+	return header
+}
@@ -0,0 +1,37 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotate(t *testing.T) {
+	files, err := Parse("diff --git a/main.go b/main.go\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -8,4 +8,5 @@ func main() {\n" +
+		" \ta := 1\n" +
+		"-\tb := 2\n" +
+		"+\tb := 3\n" +
+		"+\tc := 4\n" +
+		" \tfmt.Println(a, b)\n" +
+		" }\n" +
+		"@@ -20 +21 @@\n" +
+		"-// old\n" +
+		"+// new\n" +
+		"\\ No newline at end of file")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "@@ -8,4 +8,5 @@ func main() {\n"+
+		" 8  \ta := 1\n"+
+		"   -\tb := 2\n"+
+		" 9 +\tb := 3\n"+
+		"10 +\tc := 4\n"+
+		"11  \tfmt.Println(a, b)\n"+
+		"12  }\n"+
+		"@@ -20,1 +21,1 @@\n"+
+		"   -// old\n"+
+		"21 +// new\n"+
+		"\\ No newline at end of file", Annotate(files[0]))
+}
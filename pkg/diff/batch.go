@@ -0,0 +1,84 @@
+package diff
+
+import (
+	"fmt"
+	"path"
+)
+
+// SplitStrategy selects how formatted files are grouped into review requests
+type SplitStrategy string
+
+const (
+	// SplitBudget sends as few requests as the token budget allows
+	SplitBudget SplitStrategy = "budget"
+	// SplitFile sends a request per file
+	SplitFile SplitStrategy = "file"
+	// SplitPackage sends a request per directory, split further when it exceeds the token budget
+	SplitPackage SplitStrategy = "package"
+)
+
+// ParseSplitStrategy validates the name of a split strategy
+func ParseSplitStrategy(s string) (SplitStrategy, error) {
+	switch strategy := SplitStrategy(s); strategy {
+	case SplitBudget, SplitFile, SplitPackage:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown split strategy %q, expected %q, %q or %q", s, SplitBudget, SplitFile, SplitPackage)
+	}
+}
+
+// Split groups formatted files into review requests with the given strategy. Files keep
+// their order within and across requests, and requests stay within the budget unless a
+// single file exceeds it.
+func Split(files []FormattedFile, strategy SplitStrategy, budget int, estimate func(string) int) [][]FormattedFile {
+	switch strategy {
+	case SplitFile:
+		batches := make([][]FormattedFile, 0, len(files))
+		for _, file := range files {
+			batches = append(batches, []FormattedFile{file})
+		}
+		return batches
+	case SplitPackage:
+		var dirs []string
+		packages := make(map[string][]FormattedFile)
+		for _, file := range files {
+			dir := path.Dir(file.Path)
+			if _, ok := packages[dir]; !ok {
+				dirs = append(dirs, dir)
+			}
+			packages[dir] = append(packages[dir], file)
+		}
+
+		var batches [][]FormattedFile
+		for _, dir := range dirs {
+			batches = append(batches, Batch(packages[dir], budget, estimate)...)
+		}
+		return batches
+	default:
+		return Batch(files, budget, estimate)
+	}
+}
+
+// Batch splits formatted files into batches whose estimated size stays within a budget, keeping
+// the files in order so that files of the same directory stay together. A file larger than the
+// budget on its own is placed in a batch of its own.
+func Batch(files []FormattedFile, budget int, estimate func(string) int) [][]FormattedFile {
+	var batches [][]FormattedFile
+	var current []FormattedFile
+	size := 0
+
+	for _, file := range files {
+		fileSize := estimate(file.OriginalContent) + estimate(file.Diff)
+		if len(current) > 0 && size+fileSize > budget {
+			batches = append(batches, current)
+			current = nil
+			size = 0
+		}
+		current = append(current, file)
+		size += fileSize
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
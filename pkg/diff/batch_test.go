@@ -0,0 +1,117 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func length(s string) int {
+	return len(s)
+}
+
+func sizedFile(path string, size int) FormattedFile {
+	return FormattedFile{Path: path, Diff: string(make([]byte, size))}
+}
+
+func batchPaths(batches [][]FormattedFile) [][]string {
+	var result [][]string
+	for _, batch := range batches {
+		var names []string
+		for _, f := range batch {
+			names = append(names, f.Path)
+		}
+		result = append(result, names)
+	}
+	return result
+}
+
+func TestParseSplitStrategy(t *testing.T) {
+	for _, name := range []string{"budget", "file", "package"} {
+		strategy, err := ParseSplitStrategy(name)
+		assert.NoError(t, err)
+		assert.Equal(t, SplitStrategy(name), strategy)
+	}
+
+	_, err := ParseSplitStrategy("module")
+	assert.Error(t, err)
+}
+
+func TestSplit(t *testing.T) {
+	files := []FormattedFile{
+		sizedFile("cmd/main.go", 2),
+		sizedFile("pkg/a/a.go", 4),
+		sizedFile("pkg/a/b/b.go", 2),
+		sizedFile("pkg/a/c.go", 4),
+		sizedFile("pkg/a/d.go", 4),
+	}
+
+	tests := []struct {
+		name     string
+		strategy SplitStrategy
+		want     [][]string
+	}{
+		{
+			name:     "Budget",
+			strategy: SplitBudget,
+			want:     [][]string{{"cmd/main.go", "pkg/a/a.go", "pkg/a/b/b.go"}, {"pkg/a/c.go", "pkg/a/d.go"}},
+		},
+		{
+			name:     "File",
+			strategy: SplitFile,
+			want:     [][]string{{"cmd/main.go"}, {"pkg/a/a.go"}, {"pkg/a/b/b.go"}, {"pkg/a/c.go"}, {"pkg/a/d.go"}},
+		},
+		{
+			name:     "Package split further by budget",
+			strategy: SplitPackage,
+			want:     [][]string{{"cmd/main.go"}, {"pkg/a/a.go", "pkg/a/c.go"}, {"pkg/a/d.go"}, {"pkg/a/b/b.go"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, batchPaths(Split(files, tt.strategy, 8, length)))
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	file := sizedFile
+
+	tests := []struct {
+		name   string
+		files  []FormattedFile
+		budget int
+		want   [][]string
+	}{
+		{
+			name:   "No files",
+			budget: 10,
+			want:   nil,
+		},
+		{
+			name:   "Everything fits",
+			files:  []FormattedFile{file("a", 3), file("b", 3), file("c", 4)},
+			budget: 10,
+			want:   [][]string{{"a", "b", "c"}},
+		},
+		{
+			name:   "Split in order",
+			files:  []FormattedFile{file("a", 6), file("b", 3), file("c", 4), file("d", 1)},
+			budget: 10,
+			want:   [][]string{{"a", "b"}, {"c", "d"}},
+		},
+		{
+			name:   "Oversized file on its own",
+			files:  []FormattedFile{file("a", 2), file("big", 25), file("b", 2)},
+			budget: 10,
+			want:   [][]string{{"a"}, {"big"}, {"b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, batchPaths(Batch(tt.files, tt.budget, length)))
+		})
+	}
+}
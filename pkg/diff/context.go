@@ -0,0 +1,188 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ContextStrategy selects how much of the original content is sent along with the diff
+type ContextStrategy string
+
+const (
+	// ContextFull sends the whole original content of every changed file
+	ContextFull ContextStrategy = "full"
+	// ContextHunks sends the lines around each hunk
+	ContextHunks ContextStrategy = "hunks"
+	// ContextFunction sends the function or block enclosing each hunk
+	ContextFunction ContextStrategy = "function"
+)
+
+// DefaultContextLines is the number of lines sent before and after each hunk
+const DefaultContextLines = 10
+
+// ParseContextStrategy validates the name of a context strategy
+func ParseContextStrategy(s string) (ContextStrategy, error) {
+	switch strategy := ContextStrategy(s); strategy {
+	case ContextFull, ContextHunks, ContextFunction:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown context strategy %q, expected %q, %q or %q", s, ContextFull, ContextHunks, ContextFunction)
+	}
+}
+
+// lineRange is an inclusive range of 1-based line numbers
+type lineRange struct {
+	start int
+	end   int
+}
+
+var (
+	// functionStart matches lines that declare a function, method or type in common languages
+	functionStart = regexp.MustCompile(`^\s*(export\s+)?(default\s+)?(async\s+)?(func|def|function|fn|fun|sub|class|impl|interface|struct|type|module|public|private|protected|internal|static)\b`)
+	// blockStart matches lines opening a block with a parameter list, such as C-style function signatures
+	blockStart = regexp.MustCompile(`\)[^;]*\{\s*$`)
+	// controlStatement matches block openers that are statements rather than declarations
+	controlStatement = regexp.MustCompile(`^\s*(\}\s*)?(if|else|for|foreach|while|switch|case|catch|try|do|with|select|go|defer|return)\b`)
+	// blockEnd matches lines closing a block
+	blockEnd = regexp.MustCompile(`^\s*(\}|\)|\]|end\b)`)
+)
+
+// contextRanges returns the ranges of the original content to send for the hunks of a file
+func contextRanges(strategy ContextStrategy, contextLines int, lines []string, fileDiff FileDiff) []lineRange {
+	var ranges []lineRange
+	for _, hunk := range fileDiff.Hunks {
+		changed := hunkRange(hunk)
+
+		expanded := lineRange{start: changed.start - contextLines, end: changed.end + contextLines}
+		if strategy == ContextFunction {
+			if enclosing, ok := enclosingBlock(lines, changed); ok {
+				expanded = enclosing
+			}
+		}
+		ranges = append(ranges, expanded)
+	}
+	return mergeRanges(ranges, len(lines))
+}
+
+// hunkRange returns the range of original lines covered by a hunk. Hunks that only add
+// lines cover the line before the insertion.
+func hunkRange(hunk Hunk) lineRange {
+	if hunk.OldLines == 0 {
+		return lineRange{start: hunk.OldStart, end: hunk.OldStart}
+	}
+	return lineRange{start: hunk.OldStart, end: hunk.OldStart + hunk.OldLines - 1}
+}
+
+// enclosingBlock finds the function or type declaration enclosing a range of lines using
+// indentation and common declaration keywords
+func enclosingBlock(lines []string, changed lineRange) (lineRange, bool) {
+	if len(lines) == 0 {
+		return lineRange{}, false
+	}
+
+	first := clamp(changed.start, 1, len(lines))
+	target := indentation(lines[first-1])
+
+	start := 0
+	for i := first; i >= 1; i-- {
+		line := lines[i-1]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := indentation(line)
+		if indent > target || (indent == target && i != first) {
+			continue
+		}
+		if isDeclaration(line) {
+			start = i
+			break
+		}
+		target = indent
+	}
+	if start == 0 {
+		return lineRange{}, false
+	}
+
+	// The block ends before the next line indented at or below the declaration,
+	// including that line when it closes the block
+	startIndent := indentation(lines[start-1])
+	last := clamp(changed.end, start, len(lines))
+	end := len(lines)
+	for i := last + 1; i <= len(lines); i++ {
+		line := lines[i-1]
+		if strings.TrimSpace(line) == "" || indentation(line) > startIndent {
+			continue
+		}
+		if blockEnd.MatchString(line) {
+			end = i
+		} else {
+			end = i - 1
+		}
+		break
+	}
+	for end > last && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return lineRange{start: start, end: end}, true
+}
+
+// isDeclaration reports whether a line looks like the start of a function, method or type
+func isDeclaration(line string) bool {
+	if controlStatement.MatchString(line) {
+		return false
+	}
+	return functionStart.MatchString(line) || blockStart.MatchString(line)
+}
+
+// indentation returns the width of the leading whitespace of a line, counting tabs as four columns
+func indentation(line string) int {
+	width := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// mergeRanges clamps ranges to the file and merges overlapping or adjacent ones
+func mergeRanges(ranges []lineRange, lineCount int) []lineRange {
+	if lineCount == 0 {
+		return nil
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start < ranges[j].start
+	})
+
+	var merged []lineRange
+	for _, r := range ranges {
+		r.start = clamp(r.start, 1, lineCount)
+		r.end = clamp(r.end, r.start, lineCount)
+		if n := len(merged); n > 0 && r.start <= merged[n-1].end+1 {
+			if r.end > merged[n-1].end {
+				merged[n-1].end = r.end
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
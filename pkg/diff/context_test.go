@@ -0,0 +1,111 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseContextStrategy(t *testing.T) {
+	for _, name := range []string{"full", "hunks", "function"} {
+		strategy, err := ParseContextStrategy(name)
+		assert.NoError(t, err)
+		assert.Equal(t, ContextStrategy(name), strategy)
+	}
+
+	_, err := ParseContextStrategy("everything")
+	assert.Error(t, err)
+}
+
+func TestContextRanges(t *testing.T) {
+	goSource := strings.Split(`package main
+
+import "fmt"
+
+// greet prints a greeting
+func greet(name string) {
+	if name == "" {
+		name = "world"
+	}
+	fmt.Println("hello", name)
+}
+
+func main() {
+	greet("")
+}`, "\n")
+
+	pythonSource := strings.Split(`class Greeter:
+    def greet(self, name):
+        if not name:
+            name = "world"
+        print("hello", name)
+
+    def other(self):
+        pass`, "\n")
+
+	tests := []struct {
+		name     string
+		strategy ContextStrategy
+		lines    int
+		source   []string
+		hunks    []Hunk
+		want     []lineRange
+	}{
+		{
+			name:     "Hunks with context lines",
+			strategy: ContextHunks,
+			lines:    1,
+			source:   goSource,
+			hunks:    []Hunk{{OldStart: 8, OldLines: 1}},
+			want:     []lineRange{{start: 7, end: 9}},
+		},
+		{
+			name:     "Hunks clamped to the file and merged",
+			strategy: ContextHunks,
+			lines:    3,
+			source:   goSource,
+			hunks:    []Hunk{{OldStart: 1, OldLines: 1}, {OldStart: 5, OldLines: 2}, {OldStart: 14, OldLines: 1}},
+			want:     []lineRange{{start: 1, end: 9}, {start: 11, end: 15}},
+		},
+		{
+			name:     "Separate hunks",
+			strategy: ContextHunks,
+			lines:    0,
+			source:   goSource,
+			hunks:    []Hunk{{OldStart: 3, OldLines: 1}, {OldStart: 14, OldLines: 0}},
+			want:     []lineRange{{start: 3, end: 3}, {start: 14, end: 14}},
+		},
+		{
+			name:     "Enclosing Go function",
+			strategy: ContextFunction,
+			lines:    1,
+			source:   goSource,
+			hunks:    []Hunk{{OldStart: 8, OldLines: 1}},
+			want:     []lineRange{{start: 6, end: 11}},
+		},
+		{
+			name:     "Enclosing Python method",
+			strategy: ContextFunction,
+			lines:    1,
+			source:   pythonSource,
+			hunks:    []Hunk{{OldStart: 4, OldLines: 1}},
+			want:     []lineRange{{start: 2, end: 5}},
+		},
+		{
+			name:     "Falls back to context lines outside functions",
+			strategy: ContextFunction,
+			lines:    1,
+			source:   goSource,
+			hunks:    []Hunk{{OldStart: 3, OldLines: 1}},
+			want:     []lineRange{{start: 2, end: 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := contextRanges(tt.strategy, tt.lines, tt.source, FileDiff{Hunks: tt.hunks})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -12,80 +12,236 @@ import (
 type Formatter struct {
 	ignoredPatterns []string
 	gitClient       git.IGit
+	contextStrategy ContextStrategy
+	contextLines    int
 }
 
-// NewFormatter creates a new diff formatter
-func NewFormatter(ignoredPatterns []string) IDiff {
+// NewFormatter creates a new diff formatter reading original content through the given Git client
+func NewFormatter(gitClient git.IGit, ignoredPatterns []string) IDiff {
 	return &Formatter{
 		ignoredPatterns: ignoredPatterns,
-		gitClient:       git.NewClient(),
+		gitClient:       gitClient,
+		contextStrategy: ContextFull,
+		contextLines:    DefaultContextLines,
 	}
 }
 
-// Format prepares the git diff output for AI model review, separating original content and diff content
-func (f *Formatter) Format(diff string, changedFiles []string) (string, string, []error) {
-	fileChanges := strings.Split(diff, "diff --git")
-
-	var originalContent strings.Builder
-	var diffContent strings.Builder
-	var errors []error
+// SetContext selects how much of the original content is sent. The number of lines is
+// the context around each hunk for the hunks strategy, and the fallback for the function
+// strategy when no enclosing function is found.
+func (f *Formatter) SetContext(strategy ContextStrategy, lines int) {
+	f.contextStrategy = strategy
+	f.contextLines = lines
+}
 
-	originalContent.WriteString("<original-content>\n")
-	diffContent.WriteString("<git-diff>\n")
+// FormattedFile is the formatted original content and diff of a single file
+type FormattedFile struct {
+	Path string
+	// OldPath is the path before the change, empty for added files
+	OldPath string
+	// OriginalContent is the file's element of the original content, empty for files described by a
+	// summary and when there is no base revision
+	OriginalContent string
+	// Diff is the file's element of the diff
+	Diff string
+	// Hunks are the changed lines of the file, empty for files described by a summary
+	Hunks []Hunk
+}
 
-	for _, change := range fileChanges {
-		if change == "" {
-			continue
-		}
+// Format prepares the git diff output for AI model review, separating original content and diff content.
+// The original content of each file is read at the given base revision, and is left out when the base
+// revision is empty, for diffs that do not come from the repository. Both outputs are empty when no
+// files are left to review after applying the ignore patterns.
+func (f *Formatter) Format(diff string, baseRevision string) (string, string, []error) {
+	files, errors := f.FormatFiles(diff, baseRevision)
+	originalContent, diffContent := JoinFiles(files)
+	return originalContent, diffContent, errors
+}
 
-		change = "diff --git" + change
+// FormatFiles formats the original content and diff of every file that is not ignored separately,
+// so that the files can be split into several requests. Use JoinFiles to combine them.
+func (f *Formatter) FormatFiles(diff string, baseRevision string) ([]FormattedFile, []error) {
+	fileDiffs, err := Parse(diff)
+	if err != nil {
+		return nil, []error{fmt.Errorf("failed to parse diff: %v", err)}
+	}
 
-		fileNameStart := strings.Index(change, "a/")
-		fileNameEnd := strings.Index(change, "\n")
-		fileName := change[fileNameStart+2 : fileNameEnd]
-		fileName = f.cleanFilePath(fileName)
+	var files []FormattedFile
+	var errors []error
+	packages := newGoPackages(f.gitClient, baseRevision)
 
+	for _, fileDiff := range fileDiffs {
+		fileName := fileDiff.Path()
 		if f.shouldIgnoreFile(fileName) {
 			continue
 		}
 
-		originalContent.WriteString(fmt.Sprintf("  <file path=\"%s\">\n", f.escapeXML(fileName)))
+		var originalContent strings.Builder
+		var diffContent strings.Builder
+
 		diffContent.WriteString("  <file>\n")
 		diffContent.WriteString(fmt.Sprintf("    <name>%s</name>\n", f.escapeXML(fileName)))
+		diffContent.WriteString(fmt.Sprintf("    <change-type>%s</change-type>\n", fileDiff.Type))
+		if fileDiff.Type == ChangeRenamed || fileDiff.Type == ChangeCopied {
+			diffContent.WriteString(fmt.Sprintf("    <old-name>%s</old-name>\n", f.escapeXML(fileDiff.OldPath)))
+		}
 
-		branchPoint, err := f.gitClient.ExecCommand("git", "merge-base", "HEAD", "@{-1}")
-		if err != nil {
-			errors = append(errors, fmt.Errorf("failed to find branch point for %s: %v", fileName, err))
+		// Deletions, pure renames and binary files are described without their content
+		if summary := summarize(fileDiff); summary != "" {
+			diffContent.WriteString(fmt.Sprintf("    <summary>%s</summary>\n", f.escapeXML(summary)))
+			diffContent.WriteString("  </file>\n")
+			files = append(files, FormattedFile{Path: fileName, OldPath: fileDiff.OldPath, Diff: diffContent.String()})
 			continue
 		}
 
-		fileContent, err := f.gitClient.GetFileContentAtBranchPoint(fileName, branchPoint)
+		diffContent.WriteString("    <changes>\n")
+		diffContent.WriteString(fmt.Sprintf("      <![CDATA[%s]]>\n", Annotate(fileDiff)))
+		diffContent.WriteString("    </changes>\n")
+		diffContent.WriteString("  </file>\n")
+
+		if baseRevision == "" {
+			files = append(files, FormattedFile{Path: fileName, OldPath: fileDiff.OldPath, Diff: diffContent.String(), Hunks: fileDiff.Hunks})
+			continue
+		}
+
+		if fileDiff.Type == ChangeRenamed || fileDiff.Type == ChangeCopied {
+			originalContent.WriteString(fmt.Sprintf("  <file path=\"%s\" original-path=\"%s\">\n", f.escapeXML(fileName), f.escapeXML(fileDiff.OldPath)))
+		} else {
+			originalContent.WriteString(fmt.Sprintf("  <file path=\"%s\">\n", f.escapeXML(fileName)))
+		}
+
+		fileContent, err := f.originalContent(fileDiff, baseRevision)
 		if err != nil {
 			errors = append(errors, fmt.Errorf("failed to get original content for %s: %v", fileName, err))
 			originalContent.WriteString("    Unable to retrieve original content\n")
-		} else {
+		} else if f.contextStrategy == ContextFull || fileDiff.Type == ChangeAdded {
 			originalContent.WriteString(fmt.Sprintf("    <![CDATA[%s]]>\n", fileContent))
+		} else {
+			excerpts, err := f.excerpts(fileContent, fileDiff, packages)
+			if err != nil {
+				errors = append(errors, fmt.Errorf("failed to get context for %s: %v", fileName, err))
+			}
+			originalContent.WriteString(excerpts)
 		}
 
-		diffContent.WriteString("    <changes>\n")
-		diffContent.WriteString(fmt.Sprintf("      <![CDATA[%s]]>\n", change))
-		diffContent.WriteString("    </changes>\n")
-
 		originalContent.WriteString("  </file>\n")
-		diffContent.WriteString("  </file>\n")
+
+		files = append(files, FormattedFile{Path: fileName, OldPath: fileDiff.OldPath, OriginalContent: originalContent.String(), Diff: diffContent.String(), Hunks: fileDiff.Hunks})
+	}
+
+	return files, errors
+}
+
+// JoinFiles combines formatted files into the original content and diff sent for review.
+// Both outputs are empty when there are no files.
+func JoinFiles(files []FormattedFile) (string, string) {
+	if len(files) == 0 {
+		return "", ""
 	}
 
+	var originalContent strings.Builder
+	var diffContent strings.Builder
+
+	originalContent.WriteString("<original-content>\n")
+	diffContent.WriteString("<git-diff>\n")
+	for _, file := range files {
+		originalContent.WriteString(file.OriginalContent)
+		diffContent.WriteString(file.Diff)
+	}
 	originalContent.WriteString("</original-content>")
 	diffContent.WriteString("</git-diff>")
 
-	return originalContent.String(), diffContent.String(), errors
+	return originalContent.String(), diffContent.String()
+}
+
+// originalContent reads the content of a file before the change, following renames and copies to the source path
+func (f *Formatter) originalContent(fileDiff FileDiff, baseRevision string) (string, error) {
+	if fileDiff.Type == ChangeAdded {
+		return "[NEW FILE]", nil
+	}
+	return f.gitClient.GetFileContentAtBranchPoint(fileDiff.OldPath, baseRevision)
+}
+
+// excerpts renders the parts of the original content selected by the context strategy,
+// each tagged with its range of line numbers. For Go files, the function strategy selects
+// whole declarations and the types they reference from the syntax tree, including types
+// declared in other files of the package.
+func (f *Formatter) excerpts(fileContent string, fileDiff FileDiff, packages *goPackages) (string, error) {
+	lines := splitLines(fileContent)
+
+	var ranges []lineRange
+	var related []goExcerpt
+	var err error
+	if context, ok := f.goContext(fileContent, fileDiff); ok {
+		ranges = context.ranges
+		related, err = packages.referencedTypes(fileDiff.OldPath, context.pkg, context.external)
+	} else {
+		ranges = contextRanges(f.contextStrategy, f.contextLines, lines, fileDiff)
+	}
+
+	var sb strings.Builder
+	for _, r := range ranges {
+		excerpt := strings.Join(lines[r.start-1:r.end], "\n")
+		sb.WriteString(fmt.Sprintf("    <excerpt lines=\"%d-%d\"><![CDATA[%s]]></excerpt>\n", r.start, r.end, excerpt))
+	}
+	for _, declarations := range related {
+		for _, r := range declarations.ranges {
+			excerpt := strings.Join(declarations.lines[r.start-1:r.end], "\n")
+			sb.WriteString(fmt.Sprintf("    <excerpt path=\"%s\" lines=\"%d-%d\"><![CDATA[%s]]></excerpt>\n", f.escapeXML(declarations.path), r.start, r.end, excerpt))
+		}
+	}
+	return sb.String(), err
+}
+
+// splitLines splits file content into its lines, without the empty line after the final newline
+func splitLines(content string) []string {
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
 }
 
-// cleanFilePath removes the 'a/' and 'b/' prefixes from the file path
-func (f *Formatter) cleanFilePath(path string) string {
-	path = strings.TrimPrefix(path, "a/")
-	path = strings.TrimPrefix(path, "b/")
-	return path
+// goContext selects the context of a Go file from its syntax tree under the function strategy
+func (f *Formatter) goContext(fileContent string, fileDiff FileDiff) (goContext, bool) {
+	if f.contextStrategy != ContextFunction || !isGoFile(fileDiff.OldPath) {
+		return goContext{}, false
+	}
+	return goContextRanges(fileContent, fileDiff, f.contextLines)
+}
+
+// summarize describes changes whose content is not worth sending for review. It returns
+// an empty string for changes that should be reviewed with their diff.
+func summarize(fileDiff FileDiff) string {
+	switch {
+	case fileDiff.Binary:
+		switch fileDiff.Type {
+		case ChangeRenamed, ChangeCopied:
+			return fmt.Sprintf("Binary file %s from %s, content not shown", fileDiff.Type, fileDiff.OldPath)
+		default:
+			return fmt.Sprintf("Binary file %s, content not shown", fileDiff.Type)
+		}
+	case fileDiff.Type == ChangeDeleted:
+		removed := 0
+		for _, hunk := range fileDiff.Hunks {
+			removed += hunk.OldLines
+		}
+		return fmt.Sprintf("File deleted (%d lines removed)", removed)
+	case len(fileDiff.Hunks) > 0:
+		return ""
+	case fileDiff.Type == ChangeRenamed || fileDiff.Type == ChangeCopied:
+		verb := "Renamed"
+		if fileDiff.Type == ChangeCopied {
+			verb = "Copied"
+		}
+		summary := fmt.Sprintf("%s from %s without content changes", verb, fileDiff.OldPath)
+		if fileDiff.OldMode != fileDiff.NewMode && fileDiff.OldMode != "" && fileDiff.NewMode != "" {
+			summary += fmt.Sprintf(", mode changed from %s to %s", fileDiff.OldMode, fileDiff.NewMode)
+		}
+		return summary
+	case fileDiff.Type == ChangeAdded:
+		return "Empty file added"
+	case fileDiff.OldMode != fileDiff.NewMode:
+		return fmt.Sprintf("Mode changed from %s to %s", fileDiff.OldMode, fileDiff.NewMode)
+	default:
+		return "No content changes"
+	}
 }
 
 // shouldIgnoreFile checks if a file should be ignored based on the ignore patterns
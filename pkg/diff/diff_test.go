@@ -0,0 +1,200 @@
+package diff
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mocksgit "github.com/lmquang/code-review/mocks/pkg/git"
+)
+
+func TestFormatter_Format(t *testing.T) {
+	diff := "diff --git a/main.go b/main.go\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-old\n" +
+		"+new\n" +
+		"diff --git a/config.yaml b/config.yaml\n" +
+		"--- a/config.yaml\n" +
+		"+++ b/config.yaml\n" +
+		"@@ -1 +1 @@\n" +
+		"-a: 1\n" +
+		"+a: 2"
+
+	t.Run("Formats files and applies ignore patterns", func(t *testing.T) {
+		mockGit := new(mocksgit.IGit)
+		mockGit.On("GetFileContentAtBranchPoint", "main.go", "abc123").Return("old", nil)
+
+		formatter := NewFormatter(mockGit, []string{"*.yaml"})
+		originalContent, formattedDiff, errs := formatter.Format(diff, "abc123")
+
+		assert.Empty(t, errs)
+		assert.Equal(t, "<original-content>\n  <file path=\"main.go\">\n    <![CDATA[old]]>\n  </file>\n</original-content>", originalContent)
+		assert.Equal(t, "<git-diff>\n  <file>\n    <name>main.go</name>\n    <change-type>modified</change-type>\n    <changes>\n      <![CDATA[@@ -1,1 +1,1 @@\n  -old\n1 +new]]>\n    </changes>\n  </file>\n</git-diff>", formattedDiff)
+		mockGit.AssertExpectations(t)
+	})
+
+	t.Run("Reports errors reading original content", func(t *testing.T) {
+		mockGit := new(mocksgit.IGit)
+		mockGit.On("GetFileContentAtBranchPoint", "main.go", "abc123").Return("", errors.New("boom"))
+		mockGit.On("GetFileContentAtBranchPoint", "config.yaml", "abc123").Return("a: 1", nil)
+
+		formatter := NewFormatter(mockGit, nil)
+		originalContent, formattedDiff, errs := formatter.Format(diff, "abc123")
+
+		assert.Len(t, errs, 1)
+		assert.Contains(t, originalContent, "Unable to retrieve original content")
+		assert.Contains(t, formattedDiff, "<name>config.yaml</name>")
+		mockGit.AssertExpectations(t)
+	})
+
+	t.Run("Formats files separately", func(t *testing.T) {
+		mockGit := new(mocksgit.IGit)
+		mockGit.On("GetFileContentAtBranchPoint", "main.go", "abc123").Return("old", nil)
+		mockGit.On("GetFileContentAtBranchPoint", "config.yaml", "abc123").Return("a: 1", nil)
+
+		formatter := NewFormatter(mockGit, nil)
+		files, errs := formatter.FormatFiles(diff, "abc123")
+
+		assert.Empty(t, errs)
+		assert.Len(t, files, 2)
+		assert.Equal(t, "main.go", files[0].Path)
+		assert.Equal(t, "main.go", files[0].OldPath)
+		assert.Equal(t, "  <file path=\"main.go\">\n    <![CDATA[old]]>\n  </file>\n", files[0].OriginalContent)
+		assert.Equal(t, "config.yaml", files[1].Path)
+
+		originalContent, formattedDiff := JoinFiles(files[1:])
+		assert.Equal(t, "<original-content>\n  <file path=\"config.yaml\">\n    <![CDATA[a: 1]]>\n  </file>\n</original-content>", originalContent)
+		assert.NotContains(t, formattedDiff, "main.go")
+
+		originalContent, formattedDiff = JoinFiles(nil)
+		assert.Empty(t, originalContent)
+		assert.Empty(t, formattedDiff)
+		mockGit.AssertExpectations(t)
+	})
+
+	t.Run("Leaves out original content without a base revision", func(t *testing.T) {
+		mockGit := new(mocksgit.IGit)
+
+		formatter := NewFormatter(mockGit, nil)
+		formatter.SetContext(ContextFunction, DefaultContextLines)
+		files, errs := formatter.FormatFiles(diff, "")
+
+		assert.Empty(t, errs)
+		assert.Len(t, files, 2)
+		assert.Empty(t, files[0].OriginalContent)
+		assert.NotEmpty(t, files[0].Hunks)
+
+		originalContent, formattedDiff := JoinFiles(files)
+		assert.Equal(t, "<original-content>\n</original-content>", originalContent)
+		assert.Contains(t, formattedDiff, "<name>config.yaml</name>")
+		mockGit.AssertExpectations(t)
+	})
+
+	t.Run("Everything ignored", func(t *testing.T) {
+		formatter := NewFormatter(new(mocksgit.IGit), []string{"*.go", "*.yaml"})
+		originalContent, formattedDiff, errs := formatter.Format(diff, "abc123")
+
+		assert.Empty(t, errs)
+		assert.Empty(t, originalContent)
+		assert.Empty(t, formattedDiff)
+	})
+
+	t.Run("Sends only the lines around each hunk", func(t *testing.T) {
+		diff := "diff --git a/main.go b/main.go\n" +
+			"--- a/main.go\n" +
+			"+++ b/main.go\n" +
+			"@@ -3 +3 @@\n" +
+			"-three\n" +
+			"+THREE\n" +
+			"@@ -9 +9 @@\n" +
+			"-nine\n" +
+			"+NINE"
+
+		mockGit := new(mocksgit.IGit)
+		mockGit.On("GetFileContentAtBranchPoint", "main.go", "abc123").Return("one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten", nil)
+
+		formatter := NewFormatter(mockGit, nil)
+		formatter.SetContext(ContextHunks, 1)
+		originalContent, _, errs := formatter.Format(diff, "abc123")
+
+		assert.Empty(t, errs)
+		assert.Equal(t, "<original-content>\n  <file path=\"main.go\">\n"+
+			"    <excerpt lines=\"2-4\"><![CDATA[two\nthree\nfour]]></excerpt>\n"+
+			"    <excerpt lines=\"8-10\"><![CDATA[eight\nnine\nten]]></excerpt>\n"+
+			"  </file>\n</original-content>", originalContent)
+		mockGit.AssertExpectations(t)
+	})
+
+	t.Run("Keeps the line numbers of files starting with blank lines", func(t *testing.T) {
+		diff := "diff --git a/main.go b/main.go\n" +
+			"--- a/main.go\n" +
+			"+++ b/main.go\n" +
+			"@@ -3 +3 @@\n" +
+			"-three\n" +
+			"+THREE"
+
+		mockGit := new(mocksgit.IGit)
+		mockGit.On("GetFileContentAtBranchPoint", "main.go", "abc123").Return("\n\nthree\nfour\n", nil)
+
+		formatter := NewFormatter(mockGit, nil)
+		formatter.SetContext(ContextHunks, 2)
+		originalContent, _, errs := formatter.Format(diff, "abc123")
+
+		assert.Empty(t, errs)
+		assert.Contains(t, originalContent, "<excerpt lines=\"1-4\"><![CDATA[\n\nthree\nfour]]></excerpt>")
+		mockGit.AssertExpectations(t)
+	})
+
+	t.Run("Describes deletions, renames and binary files", func(t *testing.T) {
+		diff := "diff --git a/removed.go b/removed.go\n" +
+			"deleted file mode 100644\n" +
+			"--- a/removed.go\n" +
+			"+++ /dev/null\n" +
+			"@@ -1,2 +0,0 @@\n" +
+			"-package removed\n" +
+			"-\n" +
+			"diff --git a/old.go b/new.go\n" +
+			"similarity index 100%\n" +
+			"rename from old.go\n" +
+			"rename to new.go\n" +
+			"diff --git a/moved.go b/edited.go\n" +
+			"similarity index 90%\n" +
+			"rename from moved.go\n" +
+			"rename to edited.go\n" +
+			"--- a/moved.go\n" +
+			"+++ b/edited.go\n" +
+			"@@ -1 +1 @@\n" +
+			"-old\n" +
+			"+new\n" +
+			"diff --git a/logo.png b/logo.png\n" +
+			"new file mode 100644\n" +
+			"Binary files /dev/null and b/logo.png differ\n" +
+			"diff --git a/added.go b/added.go\n" +
+			"new file mode 100644\n" +
+			"--- /dev/null\n" +
+			"+++ b/added.go\n" +
+			"@@ -0,0 +1 @@\n" +
+			"+package added"
+
+		mockGit := new(mocksgit.IGit)
+		mockGit.On("GetFileContentAtBranchPoint", "moved.go", "abc123").Return("old", nil)
+
+		formatter := NewFormatter(mockGit, nil)
+		originalContent, formattedDiff, errs := formatter.Format(diff, "abc123")
+
+		assert.Empty(t, errs)
+		assert.Contains(t, formattedDiff, "<name>removed.go</name>\n    <change-type>deleted</change-type>\n    <summary>File deleted (2 lines removed)</summary>")
+		assert.Contains(t, formattedDiff, "<name>new.go</name>\n    <change-type>renamed</change-type>\n    <old-name>old.go</old-name>\n    <summary>Renamed from old.go without content changes</summary>")
+		assert.Contains(t, formattedDiff, "<name>logo.png</name>\n    <change-type>added</change-type>\n    <summary>Binary file added, content not shown</summary>")
+		assert.Contains(t, formattedDiff, "<name>edited.go</name>\n    <change-type>renamed</change-type>\n    <old-name>moved.go</old-name>\n    <changes>")
+		assert.NotContains(t, formattedDiff, "package removed")
+		assert.Equal(t, "<original-content>\n"+
+			"  <file path=\"edited.go\" original-path=\"moved.go\">\n    <![CDATA[old]]>\n  </file>\n"+
+			"  <file path=\"added.go\">\n    <![CDATA[[NEW FILE]]]>\n  </file>\n"+
+			"</original-content>", originalContent)
+		mockGit.AssertExpectations(t)
+	})
+}
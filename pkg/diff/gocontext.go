@@ -0,0 +1,346 @@
+package diff
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"strings"
+
+	"github.com/lmquang/code-review/pkg/git"
+)
+
+// goContext is the context selected from the syntax tree of a Go file
+type goContext struct {
+	// pkg is the package name of the file
+	pkg string
+	// ranges cover the imports, the declarations enclosing the hunks and the types they reference
+	ranges []lineRange
+	// external are the names referenced by the enclosing declarations but not declared in the file
+	external []string
+}
+
+// goExcerpt is a set of declarations from another file of a Go package
+type goExcerpt struct {
+	path   string
+	lines  []string
+	ranges []lineRange
+}
+
+// goFile holds the type declarations of a Go file
+type goFile struct {
+	path  string
+	pkg   string
+	lines []string
+	types map[string]lineRange
+}
+
+// goPackages finds type declarations in the files of Go packages at a revision, reading
+// each package at most once
+type goPackages struct {
+	gitClient git.IGit
+	revision  string
+	dirs      map[string][]goFile
+}
+
+func newGoPackages(gitClient git.IGit, revision string) *goPackages {
+	return &goPackages{
+		gitClient: gitClient,
+		revision:  revision,
+		dirs:      make(map[string][]goFile),
+	}
+}
+
+// isGoFile reports whether a path is a Go source file
+func isGoFile(name string) bool {
+	return strings.HasSuffix(name, ".go")
+}
+
+// isGoTestFile reports whether a path is a Go test file
+func isGoTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go")
+}
+
+// goContextRanges selects the imports of a Go file, the declarations enclosing each hunk and
+// the type definitions they reference. Hunks outside of any declaration get the given number
+// of context lines. It returns false when the file cannot be parsed.
+func goContextRanges(src string, fileDiff FileDiff, contextLines int) (goContext, bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return goContext{}, false
+	}
+
+	context := goContext{pkg: file.Name.Name}
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			context.ranges = append(context.ranges, nodeRange(fset, gen.Doc, gen))
+		}
+	}
+
+	var enclosing []ast.Decl
+	selected := make(map[ast.Decl]bool)
+	for _, hunk := range fileDiff.Hunks {
+		changed := hunkRange(hunk)
+		found := false
+		for _, decl := range file.Decls {
+			r := declRange(fset, decl)
+			if r.start > changed.end || r.end < changed.start {
+				continue
+			}
+			found = true
+			if !selected[decl] {
+				selected[decl] = true
+				enclosing = append(enclosing, decl)
+				context.ranges = append(context.ranges, r)
+			}
+		}
+		if !found {
+			context.ranges = append(context.ranges, lineRange{start: changed.start - contextLines, end: changed.end + contextLines})
+		}
+	}
+
+	declared := declaredNames(file)
+	typeDecls := typeRanges(fset, file)
+	for _, name := range referencedNames(enclosing) {
+		if r, ok := typeDecls[name]; ok {
+			context.ranges = append(context.ranges, r)
+		} else if !declared[name] && types.Universe.Lookup(name) == nil {
+			context.external = append(context.external, name)
+		}
+	}
+
+	context.ranges = mergeRanges(context.ranges, len(splitLines(src)))
+	return context, true
+}
+
+// declRange returns the lines of a top-level declaration, including its doc comment
+func declRange(fset *token.FileSet, decl ast.Decl) lineRange {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		return nodeRange(fset, decl.Doc, decl)
+	case *ast.GenDecl:
+		return nodeRange(fset, decl.Doc, decl)
+	default:
+		return nodeRange(fset, nil, decl)
+	}
+}
+
+// nodeRange returns the lines of a node, starting at its doc comment when it has one
+func nodeRange(fset *token.FileSet, doc *ast.CommentGroup, node ast.Node) lineRange {
+	start := node.Pos()
+	if doc != nil {
+		start = doc.Pos()
+	}
+	return lineRange{start: fset.Position(start).Line, end: fset.Position(node.End()).Line}
+}
+
+// typeRanges returns the lines of every type declared at the top level of a file
+func typeRanges(fset *token.FileSet, file *ast.File) map[string]lineRange {
+	ranges := make(map[string]lineRange)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if gen.Lparen.IsValid() {
+				ranges[typeSpec.Name.Name] = nodeRange(fset, typeSpec.Doc, typeSpec)
+			} else {
+				ranges[typeSpec.Name.Name] = nodeRange(fset, gen.Doc, gen)
+			}
+		}
+	}
+	return ranges
+}
+
+// declaredNames returns the names declared at the top level of a file, including imported
+// package names but not methods
+func declaredNames(file *ast.File) map[string]bool {
+	names := make(map[string]bool)
+	for _, imp := range file.Imports {
+		if imp.Name != nil {
+			names[imp.Name.Name] = true
+		} else {
+			names[path.Base(strings.Trim(imp.Path.Value, "\""))] = true
+		}
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				names[decl.Name.Name] = true
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names[spec.Name.Name] = true
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						names[name.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// referencedNames returns the identifiers used in the declarations in order of appearance.
+// Names declared inside the declarations, such as parameters and local variables, field
+// names and the selected part of selector expressions are skipped.
+func referencedNames(decls []ast.Decl) []string {
+	locals := make(map[string]bool)
+	for _, decl := range decls {
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				if n.Recv != nil {
+					locals[n.Name.Name] = true
+				}
+				addFieldNames(locals, n.Recv)
+			case *ast.FuncType:
+				addFieldNames(locals, n.Params)
+				addFieldNames(locals, n.Results)
+			case *ast.AssignStmt:
+				if n.Tok == token.DEFINE {
+					addIdentNames(locals, n.Lhs...)
+				}
+			case *ast.RangeStmt:
+				if n.Tok == token.DEFINE {
+					addIdentNames(locals, n.Key, n.Value)
+				}
+			case *ast.ValueSpec:
+				for _, name := range n.Names {
+					locals[name.Name] = true
+				}
+			case *ast.LabeledStmt:
+				locals[n.Label.Name] = true
+			}
+			return true
+		})
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	var visit func(ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.Field:
+			ast.Inspect(n.Type, visit)
+			return false
+		case *ast.KeyValueExpr:
+			if _, ok := n.Key.(*ast.Ident); !ok {
+				ast.Inspect(n.Key, visit)
+			}
+			ast.Inspect(n.Value, visit)
+			return false
+		case *ast.Ident:
+			if !seen[n.Name] && !locals[n.Name] && n.Name != "_" {
+				seen[n.Name] = true
+				names = append(names, n.Name)
+			}
+		}
+		return true
+	}
+	for _, decl := range decls {
+		ast.Inspect(decl, visit)
+	}
+	return names
+}
+
+// addFieldNames adds the names of the fields of a list, such as parameters, to a set
+func addFieldNames(names map[string]bool, fields *ast.FieldList) {
+	if fields == nil {
+		return
+	}
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			names[name.Name] = true
+		}
+	}
+}
+
+// addIdentNames adds the expressions that are plain identifiers to a set
+func addIdentNames(names map[string]bool, exprs ...ast.Expr) {
+	for _, expr := range exprs {
+		if ident, ok := expr.(*ast.Ident); ok {
+			names[ident.Name] = true
+		}
+	}
+}
+
+// referencedTypes returns the declarations of the named types found in the other files of
+// the package of a Go file. Test files are only searched when the file is a test file.
+func (p *goPackages) referencedTypes(fileName, pkg string, names []string) ([]goExcerpt, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	files, err := p.load(path.Dir(fileName))
+	if err != nil {
+		return nil, err
+	}
+
+	var excerpts []goExcerpt
+	for _, file := range files {
+		if file.path == fileName || file.pkg != pkg || (isGoTestFile(file.path) && !isGoTestFile(fileName)) {
+			continue
+		}
+		var ranges []lineRange
+		for _, name := range names {
+			if r, ok := file.types[name]; ok {
+				ranges = append(ranges, r)
+			}
+		}
+		if len(ranges) > 0 {
+			excerpts = append(excerpts, goExcerpt{path: file.path, lines: file.lines, ranges: mergeRanges(ranges, len(file.lines))})
+		}
+	}
+	return excerpts, nil
+}
+
+// load reads and parses the Go files of a directory, skipping files that cannot be parsed
+func (p *goPackages) load(dir string) ([]goFile, error) {
+	if files, ok := p.dirs[dir]; ok {
+		return files, nil
+	}
+
+	paths, err := p.gitClient.ListFiles(dir, p.revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list package %s: %v", dir, err)
+	}
+
+	var files []goFile
+	for _, filePath := range paths {
+		if !isGoFile(filePath) {
+			continue
+		}
+		content, err := p.gitClient.GetFileContentAtBranchPoint(filePath, p.revision)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", filePath, err)
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, filePath, content, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		files = append(files, goFile{
+			path:  filePath,
+			pkg:   file.Name.Name,
+			lines: splitLines(content),
+			types: typeRanges(fset, file),
+		})
+	}
+
+	p.dirs[dir] = files
+	return files, nil
+}
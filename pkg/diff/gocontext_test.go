@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mocksgit "github.com/lmquang/code-review/mocks/pkg/git"
+)
+
+const goSource = `package shop
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Item is a product in the cart
+type Item struct {
+	Name  string
+	Price int
+}
+
+type (
+	// Discount reduces the total
+	Discount int
+
+	// Unused is not referenced
+	Unused string
+)
+
+const currency = "EUR"
+
+// Total sums the prices of the items
+func Total(items []Item, discount Discount, tax Tax) string {
+	total := 0
+	for _, item := range items {
+		total += item.Price
+	}
+	return fmt.Sprintf("%d %s", total-int(discount)+tax.Amount, strings.ToUpper(currency))
+}
+
+func other() {}`
+
+func TestGoContextRanges(t *testing.T) {
+	t.Run("Selects imports, the enclosing function and referenced types", func(t *testing.T) {
+		context, ok := goContextRanges(goSource, FileDiff{Hunks: []Hunk{{OldStart: 28, OldLines: 1}}}, 2)
+
+		assert.True(t, ok)
+		assert.Equal(t, "shop", context.pkg)
+		assert.Equal(t, []lineRange{{start: 3, end: 6}, {start: 8, end: 12}, {start: 15, end: 16}, {start: 24, end: 31}}, context.ranges)
+		assert.Equal(t, []string{"Tax"}, context.external)
+	})
+
+	t.Run("Falls back to context lines between declarations", func(t *testing.T) {
+		context, ok := goContextRanges(goSource, FileDiff{Hunks: []Hunk{{OldStart: 21, OldLines: 0}}}, 1)
+
+		assert.True(t, ok)
+		assert.Equal(t, []lineRange{{start: 3, end: 6}, {start: 20, end: 22}}, context.ranges)
+		assert.Empty(t, context.external)
+	})
+
+	t.Run("Keeps the line numbers of files starting with blank lines", func(t *testing.T) {
+		context, ok := goContextRanges("\n\n"+goSource+"\n", FileDiff{Hunks: []Hunk{{OldStart: 30, OldLines: 1}, {OldStart: 35, OldLines: 1}}}, 2)
+
+		assert.True(t, ok)
+		assert.Equal(t, []lineRange{{start: 5, end: 8}, {start: 10, end: 14}, {start: 17, end: 18}, {start: 26, end: 33}, {start: 35, end: 35}}, context.ranges)
+	})
+
+	t.Run("Invalid Go", func(t *testing.T) {
+		_, ok := goContextRanges("package shop\nfunc {", FileDiff{Hunks: []Hunk{{OldStart: 2, OldLines: 1}}}, 1)
+
+		assert.False(t, ok)
+	})
+}
+
+func TestFormatter_Format_GoContext(t *testing.T) {
+	diff := "diff --git a/shop/cart.go b/shop/cart.go\n" +
+		"--- a/shop/cart.go\n" +
+		"+++ b/shop/cart.go\n" +
+		"@@ -28 +28 @@\n" +
+		"-\t\ttotal += item.Price\n" +
+		"+\t\ttotal += item.Price * 2"
+
+	mockGit := new(mocksgit.IGit)
+	mockGit.On("GetFileContentAtBranchPoint", "shop/cart.go", "abc123").Return(goSource, nil)
+	mockGit.On("ListFiles", "shop", "abc123").Return([]string{"shop/cart.go", "shop/cart_test.go", "shop/README.md", "shop/tax.go"}, nil)
+	mockGit.On("GetFileContentAtBranchPoint", "shop/cart_test.go", "abc123").Return("package shop\n\ntype Tax struct{}", nil)
+	mockGit.On("GetFileContentAtBranchPoint", "shop/tax.go", "abc123").Return("package shop\n\n// Tax is added to the total\ntype Tax struct {\n\tAmount int\n}", nil)
+
+	formatter := NewFormatter(mockGit, nil)
+	formatter.SetContext(ContextFunction, 3)
+	originalContent, _, errs := formatter.Format(diff, "abc123")
+
+	assert.Empty(t, errs)
+	assert.Contains(t, originalContent, "<excerpt lines=\"24-31\"><![CDATA[// Total sums the prices of the items\nfunc Total(")
+	assert.Contains(t, originalContent, "<excerpt path=\"shop/tax.go\" lines=\"3-6\"><![CDATA[// Tax is added to the total\ntype Tax struct {\n\tAmount int\n}]]></excerpt>")
+	assert.NotContains(t, originalContent, "cart_test.go")
+	assert.NotContains(t, originalContent, "Unused")
+	assert.NotContains(t, originalContent, "func other")
+	mockGit.AssertExpectations(t)
+}
@@ -1,5 +1,7 @@
 package diff
 
 type IDiff interface {
-	Format(diff string, changedFiles []string) (string, string, []error)
+	Format(diff string, baseRevision string) (string, string, []error)
+	FormatFiles(diff string, baseRevision string) ([]FormattedFile, []error)
+	SetContext(strategy ContextStrategy, lines int)
 }
@@ -0,0 +1,352 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChangeType describes how a file changed
+type ChangeType string
+
+const (
+	ChangeModified ChangeType = "modified"
+	ChangeAdded    ChangeType = "added"
+	ChangeDeleted  ChangeType = "deleted"
+	ChangeRenamed  ChangeType = "renamed"
+	ChangeCopied   ChangeType = "copied"
+)
+
+// LineKind describes the role of a line in a hunk
+type LineKind string
+
+const (
+	LineContext LineKind = "context"
+	LineAdded   LineKind = "added"
+	LineDeleted LineKind = "deleted"
+)
+
+// FileDiff is the parsed diff of a single file
+type FileDiff struct {
+	// OldPath is the path before the change, empty for added files
+	OldPath string
+	// NewPath is the path after the change, empty for deleted files
+	NewPath string
+	Type    ChangeType
+	OldMode string
+	NewMode string
+	// Similarity is the similarity index of renames and copies, in percent
+	Similarity int
+	Binary     bool
+	Hunks      []Hunk
+	// Raw is the text of the file's diff, including its headers
+	Raw string
+}
+
+// Hunk is a contiguous block of changes within a file
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Section is the text after the hunk range, usually the enclosing function
+	Section string
+	Lines   []Line
+}
+
+// Line is a single line of a hunk
+type Line struct {
+	Kind    LineKind
+	Content string
+	// OldNumber is the line number in the old file, zero for added lines
+	OldNumber int
+	// NewNumber is the line number in the new file, zero for deleted lines
+	NewNumber int
+	// NoNewline is set when the line is not terminated by a newline
+	NoNewline bool
+}
+
+// Path returns the path of the file after the change, or before it for deleted files
+func (f FileDiff) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// Parse parses the output of 'git diff' into one FileDiff per changed file
+func Parse(diff string) ([]FileDiff, error) {
+	var files []FileDiff
+	var current *FileDiff
+	var raw []string
+	var hunk *Hunk
+	oldLine, newLine := 0, 0
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if hunk != nil {
+			current.Hunks = append(current.Hunks, *hunk)
+			hunk = nil
+		}
+		current.Raw = strings.Join(raw, "\n")
+		files = append(files, *current)
+		current = nil
+		raw = nil
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			current = &FileDiff{Type: ChangeModified}
+			current.OldPath, current.NewPath = parseDiffGitPaths(strings.TrimPrefix(line, "diff --git "))
+			raw = []string{line}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		raw = append(raw, line)
+
+		if hunk != nil {
+			remaining := oldLine < hunk.OldStart+hunk.OldLines || newLine < hunk.NewStart+hunk.NewLines
+			switch {
+			case strings.HasPrefix(line, `\`):
+				if len(hunk.Lines) > 0 {
+					hunk.Lines[len(hunk.Lines)-1].NoNewline = true
+				}
+				continue
+			case remaining && strings.HasPrefix(line, "+"):
+				hunk.Lines = append(hunk.Lines, Line{Kind: LineAdded, Content: line[1:], NewNumber: newLine})
+				newLine++
+				continue
+			case remaining && strings.HasPrefix(line, "-"):
+				hunk.Lines = append(hunk.Lines, Line{Kind: LineDeleted, Content: line[1:], OldNumber: oldLine})
+				oldLine++
+				continue
+			case remaining && (strings.HasPrefix(line, " ") || line == ""):
+				// Trailing whitespace may have been trimmed from an empty context line
+				content := line
+				if content != "" {
+					content = content[1:]
+				}
+				hunk.Lines = append(hunk.Lines, Line{Kind: LineContext, Content: content, OldNumber: oldLine, NewNumber: newLine})
+				oldLine++
+				newLine++
+				continue
+			}
+		}
+
+		if strings.HasPrefix(line, "@@ ") {
+			if hunk != nil {
+				current.Hunks = append(current.Hunks, *hunk)
+			}
+			parsed, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hunk in %s: %v", current.Path(), err)
+			}
+			hunk = &parsed
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			continue
+		}
+
+		parseExtendedHeader(current, line)
+	}
+	flush()
+
+	return files, nil
+}
+
+// parseExtendedHeader applies a header line between 'diff --git' and the first hunk
+func parseExtendedHeader(f *FileDiff, line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		f.Type = ChangeAdded
+		f.NewMode = strings.TrimPrefix(line, "new file mode ")
+		f.OldPath = ""
+	case strings.HasPrefix(line, "deleted file mode "):
+		f.Type = ChangeDeleted
+		f.OldMode = strings.TrimPrefix(line, "deleted file mode ")
+		f.NewPath = ""
+	case strings.HasPrefix(line, "old mode "):
+		f.OldMode = strings.TrimPrefix(line, "old mode ")
+	case strings.HasPrefix(line, "new mode "):
+		f.NewMode = strings.TrimPrefix(line, "new mode ")
+	case strings.HasPrefix(line, "similarity index "):
+		f.Similarity, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+	case strings.HasPrefix(line, "rename from "):
+		f.Type = ChangeRenamed
+		f.OldPath = unquotePath(strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		f.Type = ChangeRenamed
+		f.NewPath = unquotePath(strings.TrimPrefix(line, "rename to "))
+	case strings.HasPrefix(line, "copy from "):
+		f.Type = ChangeCopied
+		f.OldPath = unquotePath(strings.TrimPrefix(line, "copy from "))
+	case strings.HasPrefix(line, "copy to "):
+		f.Type = ChangeCopied
+		f.NewPath = unquotePath(strings.TrimPrefix(line, "copy to "))
+	case strings.HasPrefix(line, "index "):
+		// index <old>..<new> [<mode>]
+		fields := strings.Fields(line)
+		if len(fields) == 3 && f.OldMode == "" && f.NewMode == "" {
+			f.OldMode, f.NewMode = fields[2], fields[2]
+		}
+	case strings.HasPrefix(line, "--- "):
+		if path := parseHeaderPath(strings.TrimPrefix(line, "--- "), "a/"); path != "" {
+			f.OldPath = path
+		}
+	case strings.HasPrefix(line, "+++ "):
+		if path := parseHeaderPath(strings.TrimPrefix(line, "+++ "), "b/"); path != "" {
+			f.NewPath = path
+		}
+	case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+		f.Binary = true
+	}
+}
+
+// parseHunkHeader parses a hunk header such as '@@ -1,3 +1,4 @@ func main() {'
+func parseHunkHeader(line string) (Hunk, error) {
+	var hunk Hunk
+
+	rest := strings.TrimPrefix(line, "@@ ")
+	ranges, section, found := strings.Cut(rest, " @@")
+	if !found {
+		return hunk, fmt.Errorf("malformed hunk header %q", line)
+	}
+	hunk.Section = strings.TrimSpace(section)
+
+	oldRange, newRange, found := strings.Cut(ranges, " ")
+	if !found || !strings.HasPrefix(oldRange, "-") || !strings.HasPrefix(newRange, "+") {
+		return hunk, fmt.Errorf("malformed hunk header %q", line)
+	}
+
+	var err error
+	if hunk.OldStart, hunk.OldLines, err = parseRange(oldRange[1:]); err != nil {
+		return hunk, fmt.Errorf("malformed hunk header %q: %v", line, err)
+	}
+	if hunk.NewStart, hunk.NewLines, err = parseRange(newRange[1:]); err != nil {
+		return hunk, fmt.Errorf("malformed hunk header %q: %v", line, err)
+	}
+	return hunk, nil
+}
+
+// parseRange parses a hunk range 'start,count', where the count defaults to 1
+func parseRange(s string) (int, int, error) {
+	startText, countText, hasCount := strings.Cut(s, ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !hasCount {
+		return start, 1, nil
+	}
+	count, err := strconv.Atoi(countText)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, count, nil
+}
+
+// parseHeaderPath parses the path of a '---' or '+++' line, returning an empty string for /dev/null
+func parseHeaderPath(path, prefix string) string {
+	// git appends a tab to paths containing spaces
+	path = unquotePath(strings.TrimSuffix(path, "\t"))
+	if path == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+// parseDiffGitPaths extracts the old and new paths from the 'a/<old> b/<new>' part of a
+// 'diff --git' line. The line is ambiguous when unquoted paths contain spaces, so the result
+// is only a fallback for diffs without '---'/'+++' or rename headers.
+func parseDiffGitPaths(s string) (string, string) {
+	// Paths with special characters are quoted individually
+	if strings.HasPrefix(s, `"`) {
+		if end := closingQuote(s); end > 0 && end+1 < len(s) {
+			return strings.TrimPrefix(unquotePath(s[:end+1]), "a/"), strings.TrimPrefix(unquotePath(s[end+2:]), "b/")
+		}
+	}
+	if oldPart, newPart, found := strings.Cut(s, ` "b/`); found && strings.HasSuffix(newPart, `"`) {
+		return strings.TrimPrefix(oldPart, "a/"), strings.TrimPrefix(unquotePath(`"b/`+newPart), "b/")
+	}
+
+	// When both paths are equal the line is 'a/<path> b/<path>'
+	if len(s)%2 == 1 {
+		half := (len(s) - 1) / 2
+		oldPart, newPart := s[:half], s[half+1:]
+		if strings.HasPrefix(oldPart, "a/") && strings.HasPrefix(newPart, "b/") && oldPart[2:] == newPart[2:] {
+			return oldPart[2:], newPart[2:]
+		}
+	}
+
+	oldPart, newPart, found := strings.Cut(s, " b/")
+	if !found {
+		return s, s
+	}
+	return strings.TrimPrefix(oldPart, "a/"), newPart
+}
+
+// closingQuote returns the index of the quote closing the quoted string at the start of s, or -1
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// unquotePath decodes a path quoted with git's C-style escaping, such as "tab\tname" or
+// "\303\274.txt". Paths that are not quoted are returned unchanged.
+func unquotePath(path string) string {
+	if len(path) < 2 || path[0] != '"' || path[len(path)-1] != '"' {
+		return path
+	}
+
+	quoted := path[1 : len(path)-1]
+	var out []byte
+	for i := 0; i < len(quoted); i++ {
+		c := quoted[i]
+		if c != '\\' || i+1 == len(quoted) {
+			out = append(out, c)
+			continue
+		}
+
+		i++
+		switch quoted[i] {
+		case 'a':
+			out = append(out, '\a')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'v':
+			out = append(out, '\v')
+		case '0', '1', '2', '3':
+			// Three octal digits encode a single byte of a UTF-8 sequence
+			if i+2 < len(quoted) {
+				if value, err := strconv.ParseUint(quoted[i:i+3], 8, 8); err == nil {
+					out = append(out, byte(value))
+					i += 2
+					continue
+				}
+			}
+			out = append(out, quoted[i])
+		default:
+			out = append(out, quoted[i])
+		}
+	}
+	return string(out)
+}
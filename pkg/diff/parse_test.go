@@ -0,0 +1,315 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		diff    string
+		want    []FileDiff
+		wantErr bool
+	}{
+		{
+			name: "Modified file with multiple hunks",
+			diff: "diff --git a/main.go b/main.go\n" +
+				"index 1234567..890abcd 100644\n" +
+				"--- a/main.go\n" +
+				"+++ b/main.go\n" +
+				"@@ -1,3 +1,3 @@ package main\n" +
+				" line1\n" +
+				"-line2\n" +
+				"+updated2\n" +
+				" line3\n" +
+				"@@ -10 +10,2 @@ func main() {\n" +
+				" line10\n" +
+				"+line11",
+			want: []FileDiff{
+				{
+					OldPath: "main.go",
+					NewPath: "main.go",
+					Type:    ChangeModified,
+					OldMode: "100644",
+					NewMode: "100644",
+					Hunks: []Hunk{
+						{
+							OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3,
+							Section: "package main",
+							Lines: []Line{
+								{Kind: LineContext, Content: "line1", OldNumber: 1, NewNumber: 1},
+								{Kind: LineDeleted, Content: "line2", OldNumber: 2},
+								{Kind: LineAdded, Content: "updated2", NewNumber: 2},
+								{Kind: LineContext, Content: "line3", OldNumber: 3, NewNumber: 3},
+							},
+						},
+						{
+							OldStart: 10, OldLines: 1, NewStart: 10, NewLines: 2,
+							Section: "func main() {",
+							Lines: []Line{
+								{Kind: LineContext, Content: "line10", OldNumber: 10, NewNumber: 10},
+								{Kind: LineAdded, Content: "line11", NewNumber: 11},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Added file without trailing newline",
+			diff: "diff --git a/new.txt b/new.txt\n" +
+				"new file mode 100644\n" +
+				"index 0000000..e69de29\n" +
+				"--- /dev/null\n" +
+				"+++ b/new.txt\n" +
+				"@@ -0,0 +1 @@\n" +
+				"+hello\n" +
+				"\\ No newline at end of file",
+			want: []FileDiff{
+				{
+					NewPath: "new.txt",
+					Type:    ChangeAdded,
+					NewMode: "100644",
+					Hunks: []Hunk{
+						{
+							OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 1,
+							Lines: []Line{
+								{Kind: LineAdded, Content: "hello", NewNumber: 1, NoNewline: true},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Deleted file",
+			diff: "diff --git a/old.txt b/old.txt\n" +
+				"deleted file mode 100644\n" +
+				"index e69de29..0000000\n" +
+				"--- a/old.txt\n" +
+				"+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n" +
+				"-bye",
+			want: []FileDiff{
+				{
+					OldPath: "old.txt",
+					Type:    ChangeDeleted,
+					OldMode: "100644",
+					Hunks: []Hunk{
+						{
+							OldStart: 1, OldLines: 1, NewStart: 0, NewLines: 0,
+							Lines: []Line{
+								{Kind: LineDeleted, Content: "bye", OldNumber: 1},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Pure rename",
+			diff: "diff --git a/old/name.go b/new/name.go\n" +
+				"similarity index 100%\n" +
+				"rename from old/name.go\n" +
+				"rename to new/name.go",
+			want: []FileDiff{
+				{
+					OldPath:    "old/name.go",
+					NewPath:    "new/name.go",
+					Type:       ChangeRenamed,
+					Similarity: 100,
+				},
+			},
+		},
+		{
+			name: "Mode change",
+			diff: "diff --git a/script.sh b/script.sh\n" +
+				"old mode 100644\n" +
+				"new mode 100755",
+			want: []FileDiff{
+				{
+					OldPath: "script.sh",
+					NewPath: "script.sh",
+					Type:    ChangeModified,
+					OldMode: "100644",
+					NewMode: "100755",
+				},
+			},
+		},
+		{
+			name: "Binary file",
+			diff: "diff --git a/logo.png b/logo.png\n" +
+				"index 1234567..890abcd 100644\n" +
+				"Binary files a/logo.png and b/logo.png differ",
+			want: []FileDiff{
+				{
+					OldPath: "logo.png",
+					NewPath: "logo.png",
+					Type:    ChangeModified,
+					OldMode: "100644",
+					NewMode: "100644",
+					Binary:  true,
+				},
+			},
+		},
+		{
+			name: "Path containing a/ and a removed line starting with dashes",
+			diff: "diff --git a/data/a/b/file.sql b/data/a/b/file.sql\n" +
+				"--- a/data/a/b/file.sql\n" +
+				"+++ b/data/a/b/file.sql\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				"--- comment\n" +
+				"+++ comment\n" +
+				" SELECT 1;",
+			want: []FileDiff{
+				{
+					OldPath: "data/a/b/file.sql",
+					NewPath: "data/a/b/file.sql",
+					Type:    ChangeModified,
+					Hunks: []Hunk{
+						{
+							OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2,
+							Lines: []Line{
+								{Kind: LineDeleted, Content: "-- comment", OldNumber: 1},
+								{Kind: LineAdded, Content: "++ comment", NewNumber: 1},
+								{Kind: LineContext, Content: "SELECT 1;", OldNumber: 2, NewNumber: 2},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Quoted paths with special characters",
+			diff: "diff --git \"a/quo\\\"te.txt\" \"b/tab\\tname \\303\\274.txt\"\n" +
+				"similarity index 100%\n" +
+				"rename from \"quo\\\"te.txt\"\n" +
+				"rename to \"tab\\tname \\303\\274.txt\"",
+			want: []FileDiff{
+				{
+					OldPath:    "quo\"te.txt",
+					NewPath:    "tab\tname ü.txt",
+					Type:       ChangeRenamed,
+					Similarity: 100,
+				},
+			},
+		},
+		{
+			name: "Paths with spaces",
+			diff: "diff --git a/dir with space/a b.go b/dir with space/a b.go\n" +
+				"new file mode 100644\n" +
+				"--- /dev/null\n" +
+				"+++ b/dir with space/a b.go\t\n" +
+				"@@ -0,0 +1 @@\n" +
+				"+package a",
+			want: []FileDiff{
+				{
+					NewPath: "dir with space/a b.go",
+					Type:    ChangeAdded,
+					NewMode: "100644",
+					Hunks: []Hunk{
+						{
+							OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 1,
+							Lines: []Line{
+								{Kind: LineAdded, Content: "package a", NewNumber: 1},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Binary file with quoted path",
+			diff: "diff --git \"a/\\303\\274.png\" \"b/\\303\\274.png\"\n" +
+				"index 1234567..890abcd 100644\n" +
+				"Binary files \"a/\\303\\274.png\" and \"b/\\303\\274.png\" differ",
+			want: []FileDiff{
+				{
+					OldPath: "ü.png",
+					NewPath: "ü.png",
+					Type:    ChangeModified,
+					OldMode: "100644",
+					NewMode: "100644",
+					Binary:  true,
+				},
+			},
+		},
+		{
+			name: "Malformed hunk header",
+			diff: "diff --git a/main.go b/main.go\n" +
+				"@@ -a +b @@",
+			wantErr: true,
+		},
+		{
+			name: "Empty diff",
+			diff: "",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.diff)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			// Raw is checked separately so the expectations stay readable
+			for i := range got {
+				got[i].Raw = ""
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParse_Raw(t *testing.T) {
+	diff := "diff --git a/a.txt b/a.txt\n" +
+		"--- a/a.txt\n" +
+		"+++ b/a.txt\n" +
+		"@@ -1 +1 @@\n" +
+		"-a\n" +
+		"+b\n" +
+		"diff --git a/b.txt b/b.txt\n" +
+		"--- a/b.txt\n" +
+		"+++ b/b.txt\n" +
+		"@@ -1 +1 @@\n" +
+		"-c\n" +
+		"+d"
+
+	got, err := Parse(diff)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+b", got[0].Raw)
+	assert.Equal(t, "diff --git a/b.txt b/b.txt\n--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-c\n+d", got[1].Raw)
+}
+
+func TestFileDiff_Path(t *testing.T) {
+	assert.Equal(t, "new.go", FileDiff{OldPath: "old.go", NewPath: "new.go"}.Path())
+	assert.Equal(t, "deleted.go", FileDiff{OldPath: "deleted.go"}.Path())
+}
+
+func TestUnquotePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "plain.go", want: "plain.go"},
+		{path: "with space.go", want: "with space.go"},
+		{path: `"quo\"te.txt"`, want: `quo"te.txt`},
+		{path: `"back\\slash.txt"`, want: `back\slash.txt`},
+		{path: `"tab\tname\n.txt"`, want: "tab\tname\n.txt"},
+		{path: `"\303\274n\303\257.txt"`, want: "ünï.txt"},
+		{path: `"`, want: `"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, unquotePath(tt.path))
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package git
+
+import "fmt"
+
+// Backend names accepted by NewBackend
+const (
+	BackendExec  = "exec"
+	BackendGoGit = "go-git"
+)
+
+// NewBackend creates a Git client for the repository in the current directory using
+// the named backend. An empty name selects the git binary.
+func NewBackend(backend string) (IGit, error) {
+	switch backend {
+	case "", BackendExec:
+		return NewClient(), nil
+	case BackendGoGit:
+		return NewGoGitClient(".")
+	default:
+		return nil, fmt.Errorf("unknown git backend %q, expected %q or %q", backend, BackendExec, BackendGoGit)
+	}
+}
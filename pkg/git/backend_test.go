@@ -0,0 +1,548 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// fixture is a repository with a feature branch, a staged change and an unstaged change, and
+// a 'scripted' branch of commits made in the same second
+type fixture struct {
+	dir         string
+	initial     string
+	updateA     string
+	addC        string
+	scripted    []string
+	initialTime time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := gogit.PlainInitWithOptions(dir, &gogit.PlainInitOptions{
+		InitOptions: gogit.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+	})
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to open worktree: %v", err)
+	}
+
+	f := &fixture{dir: dir, initialTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	commit := func(message string, offset time.Duration, files map[string]string) string {
+		for name, content := range files {
+			f.write(t, name, content)
+			if _, err := wt.Add(name); err != nil {
+				t.Fatalf("failed to add %s: %v", name, err)
+			}
+		}
+		signature := &object.Signature{Name: "Test", Email: "test@example.com", When: f.initialTime.Add(offset)}
+		hash, err := wt.Commit(message, &gogit.CommitOptions{Author: signature, Committer: signature})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+		return hash.String()
+	}
+
+	f.initial = commit("Initial commit", 0, map[string]string{
+		"a.txt":      "line1\nline2\nline3\n",
+		"b.txt":      "unchanged\n",
+		"padded.txt": "\n\nfirst\n",
+	})
+
+	mainRef := plumbing.NewRemoteReferenceName("origin", "main")
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(mainRef, plumbing.NewHash(f.initial))); err != nil {
+		t.Fatalf("failed to set origin/main: %v", err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference("refs/remotes/origin/HEAD", mainRef)); err != nil {
+		t.Fatalf("failed to set origin/HEAD: %v", err)
+	}
+
+	if err := wt.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("scripted"), Create: true}); err != nil {
+		t.Fatalf("failed to create scripted branch: %v", err)
+	}
+	for i := 1; i <= 4; i++ {
+		name := fmt.Sprintf("scripted %d.txt", i)
+		f.scripted = append(f.scripted, commit("Add "+name, time.Minute, map[string]string{name: "scripted\n"}))
+	}
+	if err := wt.Checkout(&gogit.CheckoutOptions{Hash: plumbing.NewHash(f.initial), Branch: plumbing.NewBranchReferenceName("feature"), Create: true}); err != nil {
+		t.Fatalf("failed to create feature branch: %v", err)
+	}
+	f.updateA = commit("Update a.txt\n\nReplace the second line.", time.Hour, map[string]string{
+		"a.txt": "line1\nchanged\nline3\n",
+	})
+	f.addC = commit("Add c.go", 2*time.Hour, map[string]string{
+		"c.go": "package c\n",
+	})
+
+	// Stage a change to b.txt and leave an unstaged change to a.txt
+	f.write(t, "b.txt", "staged\n")
+	if _, err := wt.Add("b.txt"); err != nil {
+		t.Fatalf("failed to stage b.txt: %v", err)
+	}
+	f.write(t, "a.txt", "line1\nchanged\nline3\nunstaged\n")
+
+	return f
+}
+
+func (f *fixture) write(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(f.dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+// backends returns a client of every available backend for the fixture repository
+func (f *fixture) backends(t *testing.T) map[string]IGit {
+	t.Helper()
+
+	backends := make(map[string]IGit)
+	if _, err := exec.LookPath("git"); err == nil {
+		backends[BackendExec] = NewClientAt(f.dir)
+	} else {
+		t.Log("git binary not found, skipping the exec backend")
+	}
+
+	client, err := NewGoGitClient(f.dir)
+	if err != nil {
+		t.Fatalf("NewGoGitClient() error = %v", err)
+	}
+	backends[BackendGoGit] = client
+	return backends
+}
+
+func TestNewBackend(t *testing.T) {
+	client, err := NewBackend("")
+	if err != nil {
+		t.Fatalf("NewBackend() error = %v", err)
+	}
+	if _, ok := client.(*Client); !ok {
+		t.Error("NewBackend(\"\") did not return a *Client")
+	}
+
+	if _, err := NewBackend("svn"); err == nil {
+		t.Error("NewBackend(\"svn\") expected an error")
+	}
+}
+
+func TestBackends(t *testing.T) {
+	f := newFixture(t)
+
+	for name, client := range f.backends(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Run("GetDefaultBranch", func(t *testing.T) {
+				got, err := client.GetDefaultBranch()
+				if err != nil {
+					t.Fatalf("GetDefaultBranch() error = %v", err)
+				}
+				if got != "origin/main" {
+					t.Errorf("GetDefaultBranch() = %v, want origin/main", got)
+				}
+			})
+
+			t.Run("GetCurrentBranch", func(t *testing.T) {
+				got, err := client.GetCurrentBranch()
+				if err != nil {
+					t.Fatalf("GetCurrentBranch() error = %v", err)
+				}
+				if got != "feature" {
+					t.Errorf("GetCurrentBranch() = %q, want feature", got)
+				}
+			})
+
+			t.Run("GetGitDir", func(t *testing.T) {
+				got, err := client.GetGitDir()
+				if err != nil {
+					t.Fatalf("GetGitDir() error = %v", err)
+				}
+				want, _ := filepath.EvalSymlinks(filepath.Join(f.dir, ".git"))
+				if got, _ = filepath.EvalSymlinks(got); got != want {
+					t.Errorf("GetGitDir() = %q, want %q", got, want)
+				}
+			})
+
+			t.Run("GetBaseRevision", func(t *testing.T) {
+				got, err := client.GetBaseRevision(DiffOptions{})
+				if err != nil {
+					t.Fatalf("GetBaseRevision() error = %v", err)
+				}
+				if got != f.initial {
+					t.Errorf("GetBaseRevision() = %v, want %v", got, f.initial)
+				}
+			})
+
+			t.Run("GetRangeDiff", func(t *testing.T) {
+				diff, err := client.GetRangeDiff(f.initial, f.updateA)
+				if err != nil {
+					t.Fatalf("GetRangeDiff() error = %v", err)
+				}
+				if got := diffPaths(diff); !reflect.DeepEqual(got, []string{"a.txt"}) {
+					t.Errorf("GetRangeDiff() paths = %v, want [a.txt]", got)
+				}
+				assertContainsLines(t, diff, []string{"-line2", "+changed"})
+			})
+
+			t.Run("GetCommits", func(t *testing.T) {
+				got, err := client.GetCommits(f.initial, "HEAD")
+				if err != nil {
+					t.Fatalf("GetCommits() error = %v", err)
+				}
+				want := []Commit{
+					{Hash: f.updateA, Message: "Update a.txt\n\nReplace the second line."},
+					{Hash: f.addC, Message: "Add c.go"},
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("GetCommits() = %v, want %v", got, want)
+				}
+			})
+
+			t.Run("GetCommits with equal timestamps", func(t *testing.T) {
+				got, err := client.GetCommits(f.initial, "scripted")
+				if err != nil {
+					t.Fatalf("GetCommits() error = %v", err)
+				}
+				var hashes []string
+				for _, commit := range got {
+					hashes = append(hashes, commit.Hash)
+				}
+				if !reflect.DeepEqual(hashes, f.scripted) {
+					t.Errorf("GetCommits() = %v, want %v", hashes, f.scripted)
+				}
+			})
+
+			t.Run("ResolveCommit", func(t *testing.T) {
+				parent, commit, err := client.ResolveCommit(f.addC)
+				if err != nil {
+					t.Fatalf("ResolveCommit() error = %v", err)
+				}
+				if parent != f.updateA || commit != f.addC {
+					t.Errorf("ResolveCommit() = %v, %v, want %v, %v", parent, commit, f.updateA, f.addC)
+				}
+
+				parent, _, err = client.ResolveCommit(f.initial)
+				if err != nil {
+					t.Fatalf("ResolveCommit() error = %v", err)
+				}
+				if parent != EmptyTree {
+					t.Errorf("ResolveCommit() of root commit parent = %v, want %v", parent, EmptyTree)
+				}
+			})
+
+			t.Run("ResolveRange", func(t *testing.T) {
+				from, to, err := client.ResolveRange("main...feature")
+				if err != nil {
+					t.Fatalf("ResolveRange() error = %v", err)
+				}
+				if from != f.initial || to != "feature" {
+					t.Errorf("ResolveRange() = %v, %v, want %v, feature", from, to, f.initial)
+				}
+			})
+
+			listTests := []struct {
+				name     string
+				dir      string
+				revision string
+				want     []string
+			}{
+				{name: "Root", dir: "", revision: f.addC, want: []string{"a.txt", "b.txt", "c.go", "padded.txt"}},
+				{name: "Earlier revision", dir: ".", revision: f.initial, want: []string{"a.txt", "b.txt", "padded.txt"}},
+				{name: "Missing directory", dir: "missing", revision: f.addC, want: nil},
+			}
+			for _, tt := range listTests {
+				t.Run("ListFiles "+tt.name, func(t *testing.T) {
+					got, err := client.ListFiles(tt.dir, tt.revision)
+					if err != nil {
+						t.Fatalf("ListFiles() error = %v", err)
+					}
+					if !reflect.DeepEqual(got, tt.want) {
+						t.Errorf("ListFiles() = %q, want %q", got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+// diffPaths returns the new paths of the files in a diff, which must not need quoting
+func diffPaths(diff string) []string {
+	var paths []string
+	for _, line := range strings.Split(diff, "\n") {
+		if header, found := strings.CutPrefix(line, "diff --git "); found {
+			_, path, _ := strings.Cut(header, " b/")
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// assertContainsLines checks that each of the wanted lines appears in the diff
+func assertContainsLines(t *testing.T, diff string, want []string) {
+	t.Helper()
+	lines := make(map[string]bool)
+	for _, line := range strings.Split(diff, "\n") {
+		lines[line] = true
+	}
+	for _, line := range want {
+		if !lines[line] {
+			t.Errorf("diff does not contain line %q:\n%s", line, diff)
+		}
+	}
+}
+
+func TestBackends_Renames(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to open worktree: %v", err)
+	}
+
+	content := "first line\nsecond line\nthird line\nfourth line\n"
+	if err := os.WriteFile(filepath.Join(dir, "old.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write old.txt: %v", err)
+	}
+	if _, err := wt.Add("old.txt"); err != nil {
+		t.Fatalf("failed to add old.txt: %v", err)
+	}
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	initial, err := wt.Commit("Add old.txt", &gogit.CommitOptions{Author: signature, Committer: signature})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	if _, err := wt.Move("old.txt", "new.txt"); err != nil {
+		t.Fatalf("failed to move old.txt: %v", err)
+	}
+	renamed, err := wt.Commit("Rename old.txt", &gogit.CommitOptions{Author: signature, Committer: signature})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	f := &fixture{dir: dir}
+	for name, client := range f.backends(t) {
+		t.Run(name, func(t *testing.T) {
+			diff, err := client.GetRangeDiff(initial.String(), renamed.String())
+			if err != nil {
+				t.Fatalf("GetRangeDiff() error = %v", err)
+			}
+			if got := diffPaths(diff); !reflect.DeepEqual(got, []string{"new.txt"}) {
+				t.Errorf("GetRangeDiff() paths = %v, want [new.txt]", got)
+			}
+			assertContainsLines(t, diff, []string{"rename from old.txt", "rename to new.txt"})
+		})
+	}
+}
+
+func TestBackends_SpecialPaths(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to open worktree: %v", err)
+	}
+
+	paths := []string{"dir with space/file name.go", "quo\"te.txt", "tab\tname.txt", "ünïcödé.txt"}
+	commitAll := func(content string) string {
+		for _, path := range paths {
+			fullPath := filepath.Join(dir, filepath.FromSlash(path))
+			if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+				t.Fatalf("failed to create directory for %s: %v", path, err)
+			}
+			if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write %s: %v", path, err)
+			}
+			if _, err := wt.Add(path); err != nil {
+				t.Fatalf("failed to add %s: %v", path, err)
+			}
+		}
+		signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+		hash, err := wt.Commit("Write "+content, &gogit.CommitOptions{Author: signature, Committer: signature})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+		return hash.String()
+	}
+	first := commitAll("v1\n")
+	second := commitAll("v2\n")
+
+	f := &fixture{dir: dir}
+	for name, client := range f.backends(t) {
+		t.Run(name, func(t *testing.T) {
+			diff, err := client.GetRangeDiff(first, second)
+			if err != nil {
+				t.Fatalf("GetRangeDiff() error = %v", err)
+			}
+			if got := strings.Count(diff, "\n+v2"); got != len(paths) {
+				t.Errorf("GetRangeDiff() changed %d files, want %d:\n%s", got, len(paths), diff)
+			}
+
+			for _, path := range paths {
+				got, err := client.GetFileContentAtBranchPoint(path, first)
+				if err != nil {
+					t.Fatalf("GetFileContentAtBranchPoint(%q) error = %v", path, err)
+				}
+				if got != "v1\n" {
+					t.Errorf("GetFileContentAtBranchPoint(%q) = %q, want %q", path, got, "v1\n")
+				}
+			}
+
+			files, err := client.ListFiles("dir with space", first)
+			if err != nil {
+				t.Fatalf("ListFiles() error = %v", err)
+			}
+			if want := []string{"dir with space/file name.go"}; !reflect.DeepEqual(files, want) {
+				t.Errorf("ListFiles() = %q, want %q", files, want)
+			}
+		})
+	}
+}
+
+func TestBackends_ModesAndSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to open worktree: %v", err)
+	}
+
+	f := &fixture{dir: dir}
+	f.write(t, "a.txt", "a\n")
+	f.write(t, "b.txt", "b\n")
+	f.write(t, "run.sh", "echo run\n")
+	if err := os.Symlink("a.txt", filepath.Join(dir, "link")); err != nil {
+		t.Skipf("symbolic links are not supported: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt", "run.sh", "link"} {
+		if _, err := wt.Add(name); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if _, err := wt.Commit("Add files", &gogit.CommitOptions{Author: signature, Committer: signature}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	// Make the script executable and point the link at b.txt, without touching the content of files
+	if err := os.Chmod(filepath.Join(dir, "run.sh"), 0755); err != nil {
+		t.Fatalf("failed to chmod run.sh: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("failed to remove link: %v", err)
+	}
+	if err := os.Symlink("b.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("failed to create link: %v", err)
+	}
+
+	want := []string{"old mode 100644", "new mode 100755", "-a.txt", "+b.txt", "\\ No newline at end of file"}
+	for name, client := range f.backends(t) {
+		t.Run(name, func(t *testing.T) {
+			diff, err := client.GetDiff(DiffOptions{Mode: DiffModeWorktree})
+			if err != nil {
+				t.Fatalf("GetDiff() error = %v", err)
+			}
+			if got := diffPaths(diff); !reflect.DeepEqual(got, []string{"link", "run.sh"}) {
+				t.Errorf("GetDiff() paths = %v, want [link run.sh]:\n%s", got, diff)
+			}
+			assertContainsLines(t, diff, want)
+
+			diff, err = client.GetDiff(DiffOptions{Mode: DiffModeStaged})
+			if err != nil {
+				t.Fatalf("GetDiff() error = %v", err)
+			}
+			if diff != "" {
+				t.Errorf("GetDiff() of the index = %q, want no changes", diff)
+			}
+		})
+	}
+
+	for _, name := range []string{"run.sh", "link"} {
+		if _, err := wt.Add(name); err != nil {
+			t.Fatalf("failed to stage %s: %v", name, err)
+		}
+	}
+	for name, client := range f.backends(t) {
+		t.Run(name+" staged", func(t *testing.T) {
+			diff, err := client.GetDiff(DiffOptions{Mode: DiffModeStaged})
+			if err != nil {
+				t.Fatalf("GetDiff() error = %v", err)
+			}
+			if got := diffPaths(diff); !reflect.DeepEqual(got, []string{"link", "run.sh"}) {
+				t.Errorf("GetDiff() paths = %v, want [link run.sh]:\n%s", got, diff)
+			}
+			assertContainsLines(t, diff, want)
+		})
+	}
+}
+
+func TestBackends_MergeCommits(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to open worktree: %v", err)
+	}
+
+	f := &fixture{dir: dir}
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	commit := func(name string, parents ...plumbing.Hash) plumbing.Hash {
+		f.write(t, name, name+"\n")
+		if _, err := wt.Add(name); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		hash, err := wt.Commit("Add "+name, &gogit.CommitOptions{Author: signature, Committer: signature, Parents: parents})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+		return hash
+	}
+	initial := commit("a.txt")
+	side := commit("b.txt")
+	if err := wt.Reset(&gogit.ResetOptions{Commit: initial, Mode: gogit.HardReset}); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	main := commit("c.txt")
+	merge := commit("d.txt", main, side)
+
+	for name, client := range f.backends(t) {
+		t.Run(name, func(t *testing.T) {
+			got, err := client.GetCommits(initial.String(), merge.String())
+			if err != nil {
+				t.Fatalf("GetCommits() error = %v", err)
+			}
+			// The order of the branches is left to the backends, as their commits share a timestamp
+			merges := make(map[string]bool)
+			for _, commit := range got {
+				merges[commit.Hash] = commit.Merge
+			}
+			want := map[string]bool{main.String(): false, side.String(): false, merge.String(): true}
+			if !reflect.DeepEqual(merges, want) {
+				t.Errorf("GetCommits() merges = %v, want %v", merges, want)
+			}
+		})
+	}
+}
@@ -3,84 +3,239 @@ package git
 import (
 	"bytes"
 	"fmt"
+	"log"
 	"os/exec"
 	"strings"
 )
 
-// Client represents a Git client
-type Client struct{}
+// Client represents a Git client backed by the git binary
+type Client struct {
+	dir string
+}
 
-// NewClient creates a new Git client
+// NewClient creates a new Git client for the repository in the current directory
 func NewClient() IGit {
 	return &Client{}
 }
 
-// GetDiff executes 'git diff' against the branch point and returns the output and changed files
-func (c *Client) GetDiff() (string, []string, error) {
-	currentBranch, err := c.ExecCommand("git", "rev-parse", "--abbrev-ref", "HEAD")
+// NewClientAt creates a new Git client for the repository in the given directory
+func NewClientAt(dir string) IGit {
+	return &Client{dir: dir}
+}
+
+// EmptyTree is the hash of git's empty tree, used as the parent of root commits
+const EmptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// defaultBranchCandidates are tried in order when origin/HEAD is not set
+var defaultBranchCandidates = []string{"origin/main", "origin/master", "main", "master"}
+
+// GetDiff executes 'git diff' against the branch point, or against HEAD for staged and
+// working tree changes, and returns the output. Renames and copies are detected.
+func (c *Client) GetDiff(opts DiffOptions) (string, error) {
+	var diffArgs []string
+	switch opts.Mode {
+	case DiffModeStaged:
+		log.Println("Comparing staged changes against HEAD")
+		diffArgs = []string{"--cached", "HEAD"}
+	case DiffModeWorktree:
+		log.Println("Comparing working tree against HEAD")
+		diffArgs = []string{"HEAD"}
+	default:
+		head := headRef(opts)
+
+		baseBranch, err := resolveBaseBranch(c, opts)
+		if err != nil {
+			return "", err
+		}
+
+		log.Printf("Comparing %s against %s", head, baseBranch)
+
+		mergeBase, err := c.mergeBase(head, baseBranch)
+		if err != nil {
+			return "", err
+		}
+		diffArgs = []string{mergeBase, head}
+	}
+
+	diff, err := c.ExecCommand("git", append([]string{"diff", "-M", "-C"}, diffArgs...)...)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to get current branch: %v", err)
+		return "", fmt.Errorf("failed to execute git diff: %v", err)
+	}
+	return diff, nil
+}
+
+// GetBaseRevision returns the revision the original file content is read from: the
+// merge-base of the head and base refs, or HEAD for staged and working tree changes
+func (c *Client) GetBaseRevision(opts DiffOptions) (string, error) {
+	if opts.Mode == DiffModeStaged || opts.Mode == DiffModeWorktree {
+		return "HEAD", nil
 	}
 
-	// Get the branch that the current branch was checked out from
-	baseBranch, err := c.ExecCommand("git", "rev-parse", "--abbrev-ref", "@{u}")
+	baseBranch, err := resolveBaseBranch(c, opts)
 	if err != nil {
-		// If there's an error (e.g., no upstream branch), fallback to 'develop'
-		baseBranch = "develop"
-	} else {
-		// The result will be in the format 'origin/branch', so we need to remove 'origin/'
-		baseBranch = strings.TrimPrefix(baseBranch, "origin/")
+		return "", err
 	}
+	return c.mergeBase(headRef(opts), baseBranch)
+}
 
-	fmt.Printf("Comparing %s against %s\n", currentBranch, baseBranch)
+// GetDefaultBranch detects the default branch of the repository from origin/HEAD,
+// falling back to the first existing branch among the common default names
+func (c *Client) GetDefaultBranch() (string, error) {
+	branch, err := c.ExecCommand("git", "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err == nil && branch != "" {
+		return branch, nil
+	}
 
-	// Find the merge-base (common ancestor) of the current branch and the base branch
-	mergeBase, err := c.ExecCommand("git", "merge-base", currentBranch, baseBranch)
+	for _, candidate := range defaultBranchCandidates {
+		if _, err := c.ExecCommand("git", "rev-parse", "--verify", "--quiet", candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no origin/HEAD and none of %s exist", strings.Join(defaultBranchCandidates, ", "))
+}
+
+// GetCurrentBranch returns the name of the branch checked out, failing when HEAD is detached
+func (c *Client) GetCurrentBranch() (string, error) {
+	branch, err := c.ExecCommand("git", "symbolic-ref", "--short", "--quiet", "HEAD")
+	if err != nil || branch == "" {
+		return "", fmt.Errorf("HEAD is not on a branch")
+	}
+	return branch, nil
+}
+
+// GetGitDir returns the absolute path of the repository's .git directory
+func (c *Client) GetGitDir() (string, error) {
+	dir, err := c.ExecCommand("git", "rev-parse", "--absolute-git-dir")
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to find merge base: %v", err)
+		return "", fmt.Errorf("failed to find git directory: %v", err)
 	}
+	return dir, nil
+}
 
-	changedFiles, err := c.ExecCommand("git", "diff", "--name-only", mergeBase, currentBranch)
+// GetRangeDiff executes 'git diff' between two revisions and returns the output.
+// Renames and copies are detected.
+func (c *Client) GetRangeDiff(from, to string) (string, error) {
+	log.Printf("Comparing %s against %s", to, from)
+
+	diff, err := c.ExecCommand("git", "diff", "-M", "-C", from, to)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to get list of changed files: %v", err)
+		return "", fmt.Errorf("failed to execute git diff: %v", err)
 	}
+	return diff, nil
+}
+
+// GetCommits returns the commits reachable from 'to' but not from 'from', oldest first
+func (c *Client) GetCommits(from, to string) ([]Commit, error) {
+	revisions := from + ".." + to
+	if from == EmptyTree {
+		revisions = to
+	}
+
+	// Each commit is written as "<hash>\x00<parent hashes>\x00<message>\x1e"
+	output, err := c.ExecCommand("git", "log", "--reverse", "--format=%H%x00%P%x00%B%x1e", revisions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %v", err)
+	}
+	return parseCommits(output), nil
+}
 
-	diff, err := c.ExecCommand("git", "diff", mergeBase, currentBranch)
+// ResolveRange resolves a range such as 'A..B' or 'A...B' to the revisions to diff.
+// The three-dot form compares B against the merge-base of A and B. An omitted side defaults to HEAD.
+func (c *Client) ResolveRange(spec string) (string, string, error) {
+	from, to, threeDot, err := splitRange(spec)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to execute git diff: %v", err)
+		return "", "", err
 	}
 
-	return diff, strings.Split(changedFiles, "\n"), nil
+	if threeDot {
+		mergeBase, err := c.mergeBase(to, from)
+		if err != nil {
+			return "", "", err
+		}
+		from = mergeBase
+	}
+	return from, to, nil
+}
+
+// ResolveCommit returns the parent of a commit and the commit itself, using the
+// empty tree as the parent of a root commit
+func (c *Client) ResolveCommit(commit string) (string, string, error) {
+	hash, err := c.ExecCommand("git", "rev-parse", "--verify", commit+"^{commit}")
+	if err != nil {
+		return "", "", fmt.Errorf("invalid commit %s: %v", commit, err)
+	}
+
+	parent, err := c.ExecCommand("git", "rev-parse", "--verify", "--quiet", hash+"^")
+	if err != nil {
+		return EmptyTree, hash, nil
+	}
+	return parent, hash, nil
 }
 
 // GetFileContentAtBranchPoint retrieves the content of a file at the branch point
 func (c *Client) GetFileContentAtBranchPoint(file, branchPoint string) (string, error) {
-	files := strings.Split(file, " ")
-	if len(files) >= 1 {
-		file = files[0]
-	} else if len(files) == 0 {
+	if file == "" {
 		return "", fmt.Errorf("invalid file path")
 	}
 	// Check if the file exists at the branch point
 	_, err := c.ExecCommand("git", "cat-file", "-e", fmt.Sprintf("%s:%s", branchPoint, file))
 	if err != nil {
-		if strings.Contains(err.Error(), "Not a valid object name") {
+		if isMissingPathError(err) {
 			return "[NEW FILE]", nil
 		}
 		return "", fmt.Errorf("error checking file existence: %v", err)
 	}
 
-	// File exists, get its content
-	content, err := c.ExecCommand("git", "show", fmt.Sprintf("%s:%s", branchPoint, file))
+	// File exists, get its content. It is not trimmed, as leading blank lines shift the line numbers.
+	content, err := execCommandRaw(c.dir, "git", "show", fmt.Sprintf("%s:%s", branchPoint, file))
 	if err != nil {
 		return "", fmt.Errorf("error getting file content: %v", err)
 	}
 	return content, nil
 }
 
+// ListFiles returns the paths of the files directly inside a directory at a revision,
+// or at the root of the repository when the directory is empty
+func (c *Client) ListFiles(dir, revision string) ([]string, error) {
+	args := []string{"--literal-pathspecs", "ls-tree", "--full-tree", "-z", revision}
+	if dir = strings.Trim(dir, "/"); dir != "" && dir != "." {
+		args = append(args, "--", dir+"/")
+	}
+	output, err := execCommandRaw(c.dir, "git", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %v", err)
+	}
+	return parseTree(output), nil
+}
+
 // ExecCommand is a helper function to execute git commands
 func (c *Client) ExecCommand(name string, args ...string) (string, error) {
+	return execCommand(c.dir, name, args...)
+}
+
+// mergeBase finds the merge-base (common ancestor) of the head and the base branch
+func (c *Client) mergeBase(head, baseBranch string) (string, error) {
+	mergeBase, err := c.ExecCommand("git", "merge-base", head, baseBranch)
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base: %v", err)
+	}
+	return mergeBase, nil
+}
+
+// execCommand runs a command in the given directory and returns its trimmed output
+func execCommand(dir, name string, args ...string) (string, error) {
+	out, err := execCommandRaw(dir, name, args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// execCommandRaw runs a command in the given directory and returns its output unmodified,
+// for output where surrounding whitespace is significant such as NUL-delimited paths
+func execCommandRaw(dir, name string, args ...string) (string, error) {
 	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
 	var out bytes.Buffer
 	var stderr bytes.Buffer
 	cmd.Stdout = &out
@@ -89,5 +244,96 @@ func (c *Client) ExecCommand(name string, args ...string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("%v: %s", err, stderr.String())
 	}
-	return strings.TrimSpace(out.String()), nil
+	return out.String(), nil
+}
+
+// splitNUL splits the NUL-delimited output of a '-z' git command
+func splitNUL(output string) []string {
+	var paths []string
+	for _, path := range strings.Split(output, "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// parseTree parses the output of 'git ls-tree -z', keeping only regular files
+func parseTree(output string) []string {
+	var files []string
+	for _, entry := range splitNUL(output) {
+		// <mode> SP <type> SP <object> TAB <path>
+		info, path, found := strings.Cut(entry, "\t")
+		fields := strings.Fields(info)
+		if !found || len(fields) != 3 || fields[1] != "blob" || fields[0] == "120000" {
+			continue
+		}
+		files = append(files, path)
+	}
+	return files
+}
+
+// isMissingPathError reports whether a 'git cat-file' error means the path does not exist at the revision
+func isMissingPathError(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "Not a valid object name") ||
+		strings.Contains(message, "does not exist in") ||
+		strings.Contains(message, "exists on disk, but not in")
+}
+
+// resolveBaseBranch returns the base ref of the options, detecting the default branch when unset
+func resolveBaseBranch(g IGit, opts DiffOptions) (string, error) {
+	if opts.Base != "" {
+		return opts.Base, nil
+	}
+	defaultBranch, err := g.GetDefaultBranch()
+	if err != nil {
+		return "", fmt.Errorf("failed to detect base branch, please provide one with -base: %v", err)
+	}
+	return defaultBranch, nil
+}
+
+// splitRange splits a range such as 'A..B' or 'A...B' into its sides, defaulting omitted sides to HEAD
+func splitRange(spec string) (string, string, bool, error) {
+	separator := ".."
+	if strings.Contains(spec, "...") {
+		separator = "..."
+	}
+
+	from, to, found := strings.Cut(spec, separator)
+	if !found {
+		return "", "", false, fmt.Errorf("invalid range %q, expected A..B or A...B", spec)
+	}
+	if from == "" {
+		from = "HEAD"
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	return from, to, separator == "...", nil
+}
+
+// headRef returns the head ref of the options, defaulting to HEAD
+func headRef(opts DiffOptions) string {
+	if opts.Head == "" {
+		return "HEAD"
+	}
+	return opts.Head
+}
+
+// parseCommits parses the output of 'git log --format=%H%x00%P%x00%B%x1e'
+func parseCommits(output string) []Commit {
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x00", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Message: strings.TrimSpace(fields[2]),
+			Merge:   len(strings.Fields(fields[1])) > 1,
+		})
+	}
+	return commits
 }
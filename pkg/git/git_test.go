@@ -1,10 +1,11 @@
 package git
 
 import (
-	"errors"
 	"reflect"
-	"strings"
 	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 )
 
 func TestNewClient(t *testing.T) {
@@ -17,241 +18,135 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
-// MockClient is a mock implementation of IGit for testing
-type MockClient struct {
-	ExecCommandFunc func(name string, args ...string) (string, error)
-}
-
-func (m *MockClient) GetDiff() (string, []string, error) {
-	currentBranch, err := m.ExecCommandFunc("git", "rev-parse", "--abbrev-ref", "HEAD")
-	if err != nil {
-		return "", nil, err
-	}
-
-	upstream, err := m.ExecCommandFunc("git", "rev-parse", "--abbrev-ref", "@{u}")
-	if err != nil {
-		upstream = "develop" // Fallback to develop
-	}
-
-	mergeBase, err := m.ExecCommandFunc("git", "merge-base", currentBranch, upstream)
-	if err != nil {
-		return "", nil, err
-	}
-
-	changedFiles, err := m.ExecCommandFunc("git", "diff", "--name-only", mergeBase, currentBranch)
-	if err != nil {
-		return "", nil, err
-	}
-
-	diff, err := m.ExecCommandFunc("git", "diff", mergeBase, currentBranch)
-	if err != nil {
-		return "", nil, err
-	}
-
-	return diff, strings.Split(changedFiles, "\n"), nil
-}
-
-func (m *MockClient) GetFileContentAtBranchPoint(file, branchPoint string) (string, error) {
-	_, err := m.ExecCommandFunc("git", "cat-file", "-e", branchPoint+":"+file)
-	if err != nil {
-		if strings.Contains(err.Error(), "Not a valid object name") {
-			return "[NEW FILE]", nil
+// withoutRefs returns a fixture setup removing references from the repository
+func withoutRefs(names ...plumbing.ReferenceName) func(t *testing.T, f *fixture) {
+	return func(t *testing.T, f *fixture) {
+		repo, err := gogit.PlainOpen(f.dir)
+		if err != nil {
+			t.Fatalf("failed to open repository: %v", err)
+		}
+		for _, name := range names {
+			if err := repo.Storer.RemoveReference(name); err != nil {
+				t.Fatalf("failed to remove %s: %v", name, err)
+			}
 		}
-		return "", err
 	}
-	return m.ExecCommandFunc("git", "show", branchPoint+":"+file)
-}
-
-func (m *MockClient) ExecCommand(name string, args ...string) (string, error) {
-	return m.ExecCommandFunc(name, args...)
 }
 
 func TestGetDiff(t *testing.T) {
 	tests := []struct {
-		name           string
-		execCommandMap map[string]struct {
-			output string
-			err    error
-		}
-		wantDiff         string
-		wantChangedFiles []string
-		wantErr          bool
+		name         string
+		setup        func(t *testing.T, f *fixture)
+		opts         DiffOptions
+		wantPaths    []string
+		wantContains []string
+		wantErr      bool
 	}{
 		{
-			name: "Successful diff",
-			execCommandMap: map[string]struct {
-				output string
-				err    error
-			}{
-				"git rev-parse --abbrev-ref HEAD":            {output: "feature-branch", err: nil},
-				"git rev-parse --abbrev-ref @{u}":            {output: "origin/main", err: nil},
-				"git merge-base feature-branch origin/main":  {output: "abc123", err: nil},
-				"git diff --name-only abc123 feature-branch": {output: "file1.go\nfile2.go", err: nil},
-				"git diff abc123 feature-branch":             {output: "diff content", err: nil},
-			},
-			wantDiff:         "diff content",
-			wantChangedFiles: []string{"file1.go", "file2.go"},
-			wantErr:          false,
+			name:         "Successful diff against origin/HEAD",
+			wantPaths:    []string{"a.txt", "c.go"},
+			wantContains: []string{"-line2", "+changed", "+package c"},
 		},
 		{
-			name: "No upstream branch, fallback to develop",
-			execCommandMap: map[string]struct {
-				output string
-				err    error
-			}{
-				"git rev-parse --abbrev-ref HEAD":            {output: "feature-branch", err: nil},
-				"git rev-parse --abbrev-ref @{u}":            {output: "", err: errors.New("no upstream branch")},
-				"git merge-base feature-branch develop":      {output: "abc123", err: nil},
-				"git diff --name-only abc123 feature-branch": {output: "file1.go\nfile2.go", err: nil},
-				"git diff abc123 feature-branch":             {output: "diff content", err: nil},
+			name: "No origin/HEAD, fallback to existing master",
+			setup: func(t *testing.T, f *fixture) {
+				withoutRefs("refs/remotes/origin/HEAD", "refs/remotes/origin/main")(t, f)
+				repo, err := gogit.PlainOpen(f.dir)
+				if err != nil {
+					t.Fatalf("failed to open repository: %v", err)
+				}
+				master := plumbing.NewHashReference("refs/remotes/origin/master", plumbing.NewHash(f.initial))
+				if err := repo.Storer.SetReference(master); err != nil {
+					t.Fatalf("failed to set origin/master: %v", err)
+				}
 			},
-			wantDiff:         "diff content",
-			wantChangedFiles: []string{"file1.go", "file2.go"},
-			wantErr:          false,
+			wantPaths:    []string{"a.txt", "c.go"},
+			wantContains: []string{"-line2", "+changed", "+package c"},
 		},
 		{
-			name: "Error getting current branch",
-			execCommandMap: map[string]struct {
-				output string
-				err    error
-			}{
-				"git rev-parse --abbrev-ref HEAD": {output: "", err: errors.New("failed to get current branch")},
-			},
-			wantDiff:         "",
-			wantChangedFiles: nil,
-			wantErr:          true,
+			name:         "Explicit base and head",
+			opts:         DiffOptions{Base: "main", Head: "feature~1"},
+			wantPaths:    []string{"a.txt"},
+			wantContains: []string{"-line2", "+changed"},
 		},
 		{
-			name: "Error getting changed files",
-			execCommandMap: map[string]struct {
-				output string
-				err    error
-			}{
-				"git rev-parse --abbrev-ref HEAD":            {output: "feature-branch", err: nil},
-				"git rev-parse --abbrev-ref @{u}":            {output: "origin/main", err: nil},
-				"git merge-base feature-branch origin/main":  {output: "abc123", err: nil},
-				"git diff --name-only abc123 feature-branch": {output: "", err: errors.New("failed to get list of changed files")},
-			},
-			wantDiff:         "",
-			wantChangedFiles: nil,
-			wantErr:          true,
+			name:         "Staged changes",
+			opts:         DiffOptions{Mode: DiffModeStaged},
+			wantPaths:    []string{"b.txt"},
+			wantContains: []string{"-unchanged", "+staged"},
+		},
+		{
+			name:         "Working tree changes",
+			opts:         DiffOptions{Mode: DiffModeWorktree},
+			wantPaths:    []string{"a.txt", "b.txt"},
+			wantContains: []string{"+unstaged", "+staged"},
+		},
+		{
+			name:    "No default branch found",
+			setup:   withoutRefs("refs/remotes/origin/HEAD", "refs/remotes/origin/main", "refs/heads/main"),
+			wantErr: true,
+		},
+		{
+			name:    "Unknown base",
+			opts:    DiffOptions{Base: "missing"},
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockClient := &MockClient{
-				ExecCommandFunc: func(name string, args ...string) (string, error) {
-					key := strings.Join(append([]string{name}, args...), " ")
-					if result, ok := tt.execCommandMap[key]; ok {
-						return result.output, result.err
-					}
-					return "", errors.New("unexpected command: " + key)
-				},
+			f := newFixture(t)
+			if tt.setup != nil {
+				tt.setup(t, f)
 			}
 
-			diff, changedFiles, err := mockClient.GetDiff()
-			if (err != nil) != tt.wantErr {
-				t.Errorf("GetDiff() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if diff != tt.wantDiff {
-				t.Errorf("GetDiff() diff = %v, want %v", diff, tt.wantDiff)
-			}
-			if !reflect.DeepEqual(changedFiles, tt.wantChangedFiles) {
-				t.Errorf("GetDiff() changedFiles = %v, want %v", changedFiles, tt.wantChangedFiles)
+			for name, client := range f.backends(t) {
+				t.Run(name, func(t *testing.T) {
+					diff, err := client.GetDiff(tt.opts)
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("GetDiff() error = %v, wantErr %v", err, tt.wantErr)
+					}
+					if got := diffPaths(diff); !reflect.DeepEqual(got, tt.wantPaths) {
+						t.Errorf("GetDiff() paths = %v, want %v", got, tt.wantPaths)
+					}
+					assertContainsLines(t, diff, tt.wantContains)
+				})
 			}
 		})
 	}
 }
 
 func TestGetFileContentAtBranchPoint(t *testing.T) {
+	f := newFixture(t)
+
 	tests := []struct {
 		name        string
 		file        string
 		branchPoint string
-		mockOutputs map[string]struct {
-			output string
-			err    error
-		}
-		want    string
-		wantErr bool
+		want        string
+		wantErr     bool
 	}{
-		{
-			name:        "Existing file",
-			file:        "test.go",
-			branchPoint: "main",
-			mockOutputs: map[string]struct {
-				output string
-				err    error
-			}{
-				"git cat-file -e main:test.go": {"", nil},
-				"git show main:test.go":        {"file content", nil},
-			},
-			want:    "file content",
-			wantErr: false,
-		},
-		{
-			name:        "New file",
-			file:        "new.go",
-			branchPoint: "main",
-			mockOutputs: map[string]struct {
-				output string
-				err    error
-			}{
-				"git cat-file -e main:new.go": {"", errors.New("Not a valid object name")},
-			},
-			want:    "[NEW FILE]",
-			wantErr: false,
-		},
-		{
-			name:        "Error checking file existence",
-			file:        "error.go",
-			branchPoint: "main",
-			mockOutputs: map[string]struct {
-				output string
-				err    error
-			}{
-				"git cat-file -e main:error.go": {"", errors.New("unexpected error")},
-			},
-			want:    "",
-			wantErr: true,
-		},
-		{
-			name:        "File path with spaces",
-			file:        "file with spaces.go",
-			branchPoint: "main",
-			mockOutputs: map[string]struct {
-				output string
-				err    error
-			}{
-				"git cat-file -e main:file with spaces.go": {"", nil},
-				"git show main:file with spaces.go":        {"file content with spaces", nil},
-			},
-			want:    "file content with spaces",
-			wantErr: false,
-		},
+		{name: "Existing file", file: "a.txt", branchPoint: f.initial, want: "line1\nline2\nline3\n"},
+		{name: "Leading blank lines", file: "padded.txt", branchPoint: f.initial, want: "\n\nfirst\n"},
+		{name: "New file", file: "c.go", branchPoint: f.initial, want: "[NEW FILE]"},
+		{name: "Empty tree", file: "a.txt", branchPoint: EmptyTree, want: "[NEW FILE]"},
+		{name: "File path with spaces", file: "scripted 1.txt", branchPoint: "scripted", want: "scripted\n"},
+		{name: "Empty file path", file: "", branchPoint: f.initial, wantErr: true},
+		{name: "Unknown branch point", file: "a.txt", branchPoint: "missing", wantErr: true},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mockClient := &MockClient{
-				ExecCommandFunc: func(name string, args ...string) (string, error) {
-					key := strings.Join(append([]string{name}, args...), " ")
-					if result, ok := tt.mockOutputs[key]; ok {
-						return result.output, result.err
+	for name, client := range f.backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := client.GetFileContentAtBranchPoint(tt.file, tt.branchPoint)
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("GetFileContentAtBranchPoint() error = %v, wantErr %v", err, tt.wantErr)
 					}
-					return "", errors.New("unexpected command: " + key)
-				},
-			}
-
-			got, err := mockClient.GetFileContentAtBranchPoint(tt.file, tt.branchPoint)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("GetFileContentAtBranchPoint() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if got != tt.want {
-				t.Errorf("GetFileContentAtBranchPoint() = %v, want %v", got, tt.want)
+					if got != tt.want {
+						t.Errorf("GetFileContentAtBranchPoint() = %q, want %q", got, tt.want)
+					}
+				})
 			}
 		})
 	}
@@ -296,3 +191,68 @@ func TestExecCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCommits(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Commit
+	}{
+		{
+			name:   "No commits",
+			output: "",
+			want:   nil,
+		},
+		{
+			name:   "Single commit",
+			output: "abc123\x00\x00Fix bug\x1e",
+			want:   []Commit{{Hash: "abc123", Message: "Fix bug"}},
+		},
+		{
+			name:   "Multiple commits with multi-line messages",
+			output: "abc123\x00\x00Add feature\n\nLonger description\n\x1e\ndef456\x00abc123\x00Fix tests\n\x1e",
+			want: []Commit{
+				{Hash: "abc123", Message: "Add feature\n\nLonger description"},
+				{Hash: "def456", Message: "Fix tests"},
+			},
+		},
+		{
+			name:   "Merge commit",
+			output: "abc123\x00def456 789abc\x00Merge branch 'feature'\n\x1e",
+			want:   []Commit{{Hash: "abc123", Message: "Merge branch 'feature'", Merge: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCommits(tt.output)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCommits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitNUL(t *testing.T) {
+	got := splitNUL("file with spaces.go\x00new\nline.txt\x00\x00")
+	want := []string{"file with spaces.go", "new\nline.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitNUL() = %q, want %q", got, want)
+	}
+	if got := splitNUL(""); got != nil {
+		t.Errorf("splitNUL(\"\") = %q, want nil", got)
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	output := "100644 blob 1111111111111111111111111111111111111111\tpkg/file name.go\x00" +
+		"100755 blob 2222222222222222222222222222222222222222\tpkg/run.sh\x00" +
+		"040000 tree 3333333333333333333333333333333333333333\tpkg/sub\x00" +
+		"120000 blob 4444444444444444444444444444444444444444\tpkg/link\x00" +
+		"160000 commit 5555555555555555555555555555555555555555\tpkg/module\x00"
+	got := parseTree(output)
+	want := []string{"pkg/file name.go", "pkg/run.sh"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTree() = %q, want %q", got, want)
+	}
+}
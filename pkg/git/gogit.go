@@ -0,0 +1,705 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	utildiff "github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// GoGitClient represents a Git client backed by the pure-Go go-git library. It
+// does not need a git binary, which makes it suitable for minimal containers.
+type GoGitClient struct {
+	repo *gogit.Repository
+	dir  string
+}
+
+// NewGoGitClient opens the repository containing the given directory with the go-git backend
+func NewGoGitClient(dir string) (IGit, error) {
+	repo, err := gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %v", err)
+	}
+	return &GoGitClient{
+		repo: repo,
+		dir:  dir,
+	}, nil
+}
+
+// GetDiff computes the diff against the branch point, or against HEAD for staged and
+// working tree changes, and returns the output
+func (c *GoGitClient) GetDiff(opts DiffOptions) (string, error) {
+	switch opts.Mode {
+	case DiffModeStaged:
+		log.Println("Comparing staged changes against HEAD")
+		return c.diffIndex(false)
+	case DiffModeWorktree:
+		log.Println("Comparing working tree against HEAD")
+		return c.diffIndex(true)
+	}
+
+	head := headRef(opts)
+
+	baseBranch, err := resolveBaseBranch(c, opts)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("Comparing %s against %s", head, baseBranch)
+
+	mergeBase, err := c.mergeBase(head, baseBranch)
+	if err != nil {
+		return "", err
+	}
+	return c.diffRevisions(mergeBase, head)
+}
+
+// GetBaseRevision returns the revision the original file content is read from: the
+// merge-base of the head and base refs, or HEAD for staged and working tree changes
+func (c *GoGitClient) GetBaseRevision(opts DiffOptions) (string, error) {
+	if opts.Mode == DiffModeStaged || opts.Mode == DiffModeWorktree {
+		return "HEAD", nil
+	}
+
+	baseBranch, err := resolveBaseBranch(c, opts)
+	if err != nil {
+		return "", err
+	}
+	return c.mergeBase(headRef(opts), baseBranch)
+}
+
+// GetDefaultBranch detects the default branch of the repository from origin/HEAD,
+// falling back to the first existing branch among the common default names
+func (c *GoGitClient) GetDefaultBranch() (string, error) {
+	ref, err := c.repo.Reference(plumbing.ReferenceName("refs/remotes/origin/HEAD"), false)
+	if err == nil && ref.Type() == plumbing.SymbolicReference {
+		return ref.Target().Short(), nil
+	}
+
+	for _, candidate := range defaultBranchCandidates {
+		if _, err := c.repo.ResolveRevision(plumbing.Revision(candidate)); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no origin/HEAD and none of %s exist", strings.Join(defaultBranchCandidates, ", "))
+}
+
+// GetCurrentBranch returns the name of the branch checked out, failing when HEAD is detached
+func (c *GoGitClient) GetCurrentBranch() (string, error) {
+	head, err := c.repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %v", err)
+	}
+	if !head.Name().IsBranch() {
+		return "", fmt.Errorf("HEAD is not on a branch")
+	}
+	return head.Name().Short(), nil
+}
+
+// GetGitDir returns the absolute path of the repository's .git directory
+func (c *GoGitClient) GetGitDir() (string, error) {
+	storage, ok := c.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", fmt.Errorf("failed to find git directory: repository is not stored on disk")
+	}
+	dir, err := filepath.Abs(storage.Filesystem().Root())
+	if err != nil {
+		return "", fmt.Errorf("failed to find git directory: %v", err)
+	}
+	return dir, nil
+}
+
+// GetRangeDiff computes the diff between two revisions and returns the output
+func (c *GoGitClient) GetRangeDiff(from, to string) (string, error) {
+	log.Printf("Comparing %s against %s", to, from)
+	return c.diffRevisions(from, to)
+}
+
+// GetCommits returns the commits reachable from 'to' but not from 'from', oldest first
+func (c *GoGitClient) GetCommits(from, to string) ([]Commit, error) {
+	toCommit, err := c.commit(to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %v", err)
+	}
+
+	excluded := make(map[plumbing.Hash]bool)
+	if from != EmptyTree {
+		fromCommit, err := c.commit(from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits: %v", err)
+		}
+		err = object.NewCommitPreorderIter(fromCommit, nil, nil).ForEach(func(commit *object.Commit) error {
+			excluded[commit.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits: %v", err)
+		}
+	}
+
+	found := make(map[plumbing.Hash]*object.Commit)
+	err = object.NewCommitPreorderIter(toCommit, excluded, nil).ForEach(func(commit *object.Commit) error {
+		found[commit.Hash] = commit
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %v", err)
+	}
+
+	// Match the order of 'git log --reverse' by listing every commit after its parents, first
+	// parents first. Commit times are not used, as rebased or scripted commits often share them.
+	type frame struct {
+		commit *object.Commit
+		parent int
+	}
+	commits := make([]Commit, 0, len(found))
+	var stack []frame
+	if _, ok := found[toCommit.Hash]; ok {
+		stack = append(stack, frame{commit: toCommit})
+	}
+	visited := map[plumbing.Hash]bool{toCommit.Hash: true}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.parent < len(top.commit.ParentHashes) {
+			hash := top.commit.ParentHashes[top.parent]
+			top.parent++
+			if parent, ok := found[hash]; ok && !visited[hash] {
+				visited[hash] = true
+				stack = append(stack, frame{commit: parent})
+			}
+			continue
+		}
+		commit := top.commit
+		stack = stack[:len(stack)-1]
+		commits = append(commits, Commit{
+			Hash:    commit.Hash.String(),
+			Message: strings.TrimSpace(commit.Message),
+			Merge:   commit.NumParents() > 1,
+		})
+	}
+	return commits, nil
+}
+
+// ResolveRange resolves a range such as 'A..B' or 'A...B' to the revisions to diff.
+// The three-dot form compares B against the merge-base of A and B. An omitted side defaults to HEAD.
+func (c *GoGitClient) ResolveRange(spec string) (string, string, error) {
+	from, to, threeDot, err := splitRange(spec)
+	if err != nil {
+		return "", "", err
+	}
+
+	if threeDot {
+		mergeBase, err := c.mergeBase(to, from)
+		if err != nil {
+			return "", "", err
+		}
+		from = mergeBase
+	}
+	return from, to, nil
+}
+
+// ResolveCommit returns the parent of a commit and the commit itself, using the
+// empty tree as the parent of a root commit
+func (c *GoGitClient) ResolveCommit(commit string) (string, string, error) {
+	resolved, err := c.commit(commit)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid commit %s: %v", commit, err)
+	}
+
+	if resolved.NumParents() == 0 {
+		return EmptyTree, resolved.Hash.String(), nil
+	}
+	return resolved.ParentHashes[0].String(), resolved.Hash.String(), nil
+}
+
+// GetFileContentAtBranchPoint retrieves the content of a file at the branch point
+func (c *GoGitClient) GetFileContentAtBranchPoint(file, branchPoint string) (string, error) {
+	if file == "" {
+		return "", fmt.Errorf("invalid file path")
+	}
+	tree, err := c.tree(branchPoint)
+	if err != nil {
+		return "", fmt.Errorf("error checking file existence: %v", err)
+	}
+
+	f, err := tree.File(file)
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return "[NEW FILE]", nil
+		}
+		return "", fmt.Errorf("error checking file existence: %v", err)
+	}
+
+	content, err := f.Contents()
+	if err != nil {
+		return "", fmt.Errorf("error getting file content: %v", err)
+	}
+	return content, nil
+}
+
+// ListFiles returns the paths of the files directly inside a directory at a revision,
+// or at the root of the repository when the directory is empty
+func (c *GoGitClient) ListFiles(dir, revision string) ([]string, error) {
+	tree, err := c.tree(revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %v", err)
+	}
+
+	if dir = strings.Trim(dir, "/"); dir != "" && dir != "." {
+		tree, err = tree.Tree(dir)
+		if errors.Is(err, object.ErrDirectoryNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %v", err)
+		}
+	} else {
+		dir = ""
+	}
+
+	var files []string
+	for _, entry := range tree.Entries {
+		if entry.Mode == filemode.Regular || entry.Mode == filemode.Executable {
+			files = append(files, path.Join(dir, entry.Name))
+		}
+	}
+	return files, nil
+}
+
+// ExecCommand is a helper function to execute external commands in the repository directory
+func (c *GoGitClient) ExecCommand(name string, args ...string) (string, error) {
+	return execCommand(c.dir, name, args...)
+}
+
+// mergeBase finds the merge-base (common ancestor) of the head and the base branch
+func (c *GoGitClient) mergeBase(head, baseBranch string) (string, error) {
+	headCommit, err := c.commit(head)
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base: %v", err)
+	}
+	baseCommit, err := c.commit(baseBranch)
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base: %v", err)
+	}
+
+	bases, err := headCommit.MergeBase(baseCommit)
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base: %v", err)
+	}
+	if len(bases) == 0 {
+		return "", fmt.Errorf("failed to find merge base: %s and %s have no common ancestor", head, baseBranch)
+	}
+	return bases[0].Hash.String(), nil
+}
+
+// commit resolves a revision to a commit
+func (c *GoGitClient) commit(revision string) (*object.Commit, error) {
+	hash, err := c.repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %v", revision, err)
+	}
+	return c.repo.CommitObject(*hash)
+}
+
+// tree resolves a revision to the tree of its commit, or to an empty tree for EmptyTree
+func (c *GoGitClient) tree(revision string) (*object.Tree, error) {
+	if revision == EmptyTree {
+		return &object.Tree{}, nil
+	}
+
+	commit, err := c.commit(revision)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
+
+// diffRevisions computes the diff between the trees of two revisions
+func (c *GoGitClient) diffRevisions(from, to string) (string, error) {
+	fromTree, err := c.tree(from)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute git diff: %v", err)
+	}
+	toTree, err := c.tree(to)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute git diff: %v", err)
+	}
+
+	// go-git detects renames but not copies
+	changes, err := object.DiffTreeWithOptions(context.Background(), fromTree, toTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return "", fmt.Errorf("failed to get list of changed files: %v", err)
+	}
+	sort.Sort(changes)
+
+	patch, err := changes.Patch()
+	if err != nil {
+		return "", fmt.Errorf("failed to execute git diff: %v", err)
+	}
+	return encodePatch(patch)
+}
+
+// diffIndex computes the diff of the index, or of the tracked files in the working tree, against HEAD.
+// The versions of the files are compared by hash and mode, so that only the blobs of the files that
+// changed are read.
+func (c *GoGitClient) diffIndex(worktree bool) (string, error) {
+	headTree, err := c.tree("HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to execute git diff: %v", err)
+	}
+	idx, err := c.repo.Storer.Index()
+	if err != nil {
+		return "", fmt.Errorf("failed to read index: %v", err)
+	}
+
+	head, err := treeFiles(headTree)
+	if err != nil {
+		return "", fmt.Errorf("failed to list files: %v", err)
+	}
+	staged := make(map[string]*patchFile, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		if entry.Mode != filemode.Submodule {
+			staged[entry.Name] = &patchFile{path: entry.Name, hash: entry.Hash, mode: entry.Mode}
+		}
+	}
+
+	var wt *worktreeFiles
+	if worktree {
+		if wt, err = c.worktreeFiles(idx); err != nil {
+			return "", err
+		}
+	}
+
+	// Tracked files are those in HEAD or in the index
+	paths := make([]string, 0, len(head)+len(staged))
+	for path := range head {
+		paths = append(paths, path)
+	}
+	for path := range staged {
+		if head[path] == nil {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var filePatches []fdiff.FilePatch
+	for _, path := range paths {
+		from, to := head[path], staged[path]
+		if worktree && to != nil {
+			if to, err = wt.file(to); err != nil {
+				return "", err
+			}
+		}
+
+		if from == nil && to == nil {
+			continue
+		}
+		if from != nil && to != nil && from.hash == to.hash && from.mode == to.mode {
+			continue
+		}
+
+		for _, f := range []*patchFile{from, to} {
+			if err := c.readContent(f); err != nil {
+				return "", err
+			}
+		}
+		filePatches = append(filePatches, newFilePatch(from, to))
+	}
+
+	return encodePatch(&patch{filePatches: filePatches})
+}
+
+// treeFiles lists the files of a tree by path without reading their content
+func treeFiles(tree *object.Tree) (map[string]*patchFile, error) {
+	files := make(map[string]*patchFile)
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry.Mode == filemode.Dir || entry.Mode == filemode.Submodule {
+			continue
+		}
+		files[name] = &patchFile{path: name, hash: entry.Hash, mode: entry.Mode}
+	}
+}
+
+// readContent reads the content of a version of a file from its blob unless it is already read
+func (c *GoGitClient) readContent(f *patchFile) error {
+	if f == nil || f.read {
+		return nil
+	}
+	blob, err := c.repo.BlobObject(f.hash)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", f.path, err)
+	}
+	content, err := readBlob(blob)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", f.path, err)
+	}
+	f.content, f.read = content, true
+	return nil
+}
+
+// worktreeFiles reads the versions of tracked files in the working tree
+type worktreeFiles struct {
+	root    string
+	entries map[string]*index.Entry
+	// indexTime is when the index was written. Files modified since are read even when their
+	// size and modification time match the index, as they may have changed in the same tick.
+	indexTime time.Time
+	// trustMode is false when core.fileMode is, in which case the modes of the index are kept
+	trustMode bool
+}
+
+// worktreeFiles prepares reading the working tree of the repository
+func (c *GoGitClient) worktreeFiles(idx *index.Index) (*worktreeFiles, error) {
+	wt, err := c.repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open working tree: %v", err)
+	}
+	cfg, err := c.repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+
+	files := &worktreeFiles{
+		root:      wt.Filesystem.Root(),
+		entries:   make(map[string]*index.Entry, len(idx.Entries)),
+		trustMode: cfg.Raw.Section("core").Option("filemode") != "false",
+	}
+	for _, entry := range idx.Entries {
+		files.entries[entry.Name] = entry
+	}
+	if dir, err := c.GetGitDir(); err == nil {
+		if info, err := os.Stat(filepath.Join(dir, "index")); err == nil {
+			files.indexTime = info.ModTime()
+		}
+	}
+	return files, nil
+}
+
+// file returns the version in the working tree of a file staged as the given version, or nil when
+// it is deleted. Files whose size and modification time match the index are not read, as git does.
+func (w *worktreeFiles) file(staged *patchFile) (*patchFile, error) {
+	fullPath := filepath.Join(w.root, filepath.FromSlash(staged.path))
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %v", staged.path, err)
+	}
+
+	mode := staged.mode
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		mode = filemode.Symlink
+	case !info.Mode().IsRegular():
+		// A directory replaced the file
+		return nil, nil
+	case w.trustMode && info.Mode()&0111 != 0:
+		mode = filemode.Executable
+	case w.trustMode || mode == filemode.Symlink:
+		mode = filemode.Regular
+	}
+
+	var content []byte
+	if mode == filemode.Symlink {
+		// Symbolic links are stored as the path they point to
+		target, err := os.Readlink(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", staged.path, err)
+		}
+		content = []byte(filepath.ToSlash(target))
+	} else {
+		if w.unchanged(w.entries[staged.path], info) {
+			return &patchFile{path: staged.path, hash: staged.hash, mode: mode}, nil
+		}
+		if content, err = os.ReadFile(fullPath); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", staged.path, err)
+		}
+	}
+	return &patchFile{
+		path:    staged.path,
+		hash:    plumbing.ComputeHash(plumbing.BlobObject, content),
+		mode:    mode,
+		content: string(content),
+		read:    true,
+	}, nil
+}
+
+// unchanged reports whether a file still has the size and modification time recorded in its index
+// entry, and was not modified in the same tick as the index was written
+func (w *worktreeFiles) unchanged(entry *index.Entry, info os.FileInfo) bool {
+	if entry == nil || w.indexTime.IsZero() {
+		return false
+	}
+	return int64(entry.Size) == info.Size() &&
+		entry.ModifiedAt.Equal(info.ModTime()) &&
+		info.ModTime().Before(w.indexTime)
+}
+
+// readBlob reads the full content of a blob
+func readBlob(blob *object.Blob) (string, error) {
+	reader, err := blob.Reader()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// encodePatch renders a patch in the unified format used by 'git diff'
+func encodePatch(p fdiff.Patch) (string, error) {
+	var out bytes.Buffer
+	if err := fdiff.NewUnifiedEncoder(&out, fdiff.DefaultContextLines).Encode(p); err != nil {
+		return "", fmt.Errorf("failed to encode diff: %v", err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// newFilePatch computes the line diff between two versions of a file
+func newFilePatch(from, to *patchFile) *filePatch {
+	fp := &filePatch{from: from, to: to}
+
+	var fromContent, toContent string
+	if from != nil {
+		fromContent = from.content
+		fp.binary = isBinary(fromContent)
+	}
+	if to != nil {
+		toContent = to.content
+		fp.binary = fp.binary || isBinary(toContent)
+	}
+	if fp.binary {
+		return fp
+	}
+
+	for _, d := range utildiff.Do(fromContent, toContent) {
+		var op fdiff.Operation
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			op = fdiff.Equal
+		case diffmatchpatch.DiffDelete:
+			op = fdiff.Delete
+		case diffmatchpatch.DiffInsert:
+			op = fdiff.Add
+		}
+		fp.chunks = append(fp.chunks, &chunk{content: d.Text, op: op})
+	}
+	return fp
+}
+
+// isBinary uses the same heuristic as git: content with a NUL byte in the first 8000 bytes is binary
+func isBinary(content string) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return strings.IndexByte(content, 0) >= 0
+}
+
+// patch implements fdiff.Patch for changes that are not stored as trees, such as the
+// index and the working tree
+type patch struct {
+	filePatches []fdiff.FilePatch
+}
+
+func (p *patch) FilePatches() []fdiff.FilePatch {
+	return p.filePatches
+}
+
+func (p *patch) Message() string {
+	return ""
+}
+
+// filePatch implements fdiff.FilePatch
+type filePatch struct {
+	from, to *patchFile
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func (fp *filePatch) IsBinary() bool {
+	return fp.binary
+}
+
+func (fp *filePatch) Files() (fdiff.File, fdiff.File) {
+	// Avoid returning typed nil pointers as non-nil interfaces
+	var from, to fdiff.File
+	if fp.from != nil {
+		from = fp.from
+	}
+	if fp.to != nil {
+		to = fp.to
+	}
+	return from, to
+}
+
+func (fp *filePatch) Chunks() []fdiff.Chunk {
+	return fp.chunks
+}
+
+// patchFile implements fdiff.File
+type patchFile struct {
+	path    string
+	hash    plumbing.Hash
+	mode    filemode.FileMode
+	content string
+	// read is whether content holds the content of the file, which is only read when it is diffed
+	read bool
+}
+
+func (f *patchFile) Hash() plumbing.Hash {
+	return f.hash
+}
+
+func (f *patchFile) Mode() filemode.FileMode {
+	return f.mode
+}
+
+func (f *patchFile) Path() string {
+	return f.path
+}
+
+// chunk implements fdiff.Chunk
+type chunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c *chunk) Content() string {
+	return c.content
+}
+
+func (c *chunk) Type() fdiff.Operation {
+	return c.op
+}
@@ -1,7 +1,48 @@
 package git
 
 type IGit interface {
-	GetDiff() (string, []string, error)
+	GetDiff(opts DiffOptions) (string, error)
+	GetBaseRevision(opts DiffOptions) (string, error)
+	GetDefaultBranch() (string, error)
+	GetCurrentBranch() (string, error)
+	GetGitDir() (string, error)
+	GetRangeDiff(from, to string) (string, error)
+	GetCommits(from, to string) ([]Commit, error)
+	ResolveRange(spec string) (string, string, error)
+	ResolveCommit(commit string) (string, string, error)
 	GetFileContentAtBranchPoint(file, branchPoint string) (string, error)
+	ListFiles(dir, revision string) ([]string, error)
 	ExecCommand(name string, args ...string) (string, error)
 }
+
+// DiffMode selects which changes GetDiff compares
+type DiffMode int
+
+const (
+	// DiffModeBranch compares the head ref against its merge-base with the base ref
+	DiffModeBranch DiffMode = iota
+	// DiffModeStaged compares the index against HEAD
+	DiffModeStaged
+	// DiffModeWorktree compares the tracked files in the working tree against HEAD
+	DiffModeWorktree
+)
+
+// DiffOptions selects the revisions compared by GetDiff
+type DiffOptions struct {
+	// Mode selects between branch, staged and working tree changes. Base and
+	// Head are only used in DiffModeBranch.
+	Mode DiffMode
+	// Base is the ref the changes are compared against. When empty, the
+	// default branch of the origin remote is used.
+	Base string
+	// Head is the ref containing the changes. Defaults to HEAD.
+	Head string
+}
+
+// Commit is a single commit with its full message
+type Commit struct {
+	Hash    string
+	Message string
+	// Merge is whether the commit has more than one parent
+	Merge bool
+}
@@ -0,0 +1,184 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/lmquang/code-review/internal/forge"
+)
+
+const (
+	// DefaultBaseURL is the endpoint of the GitHub REST API. GitHub Enterprise Server serves
+	// it at https://HOST/api/v3.
+	DefaultBaseURL = "https://api.github.com"
+	// apiVersion is the version of the REST API the requests are written for
+	apiVersion = "2022-11-28"
+)
+
+type client struct {
+	api     *forge.Client
+	baseURL string
+	repo    string
+}
+
+type pullRequestResponse struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	Base   struct {
+		SHA string `json:"sha"`
+	} `json:"base"`
+	Head struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+type commentRequest struct {
+	Body string `json:"body"`
+}
+
+type reviewRequest struct {
+	CommitID string         `json:"commit_id"`
+	Event    string         `json:"event"`
+	Comments []DraftComment `json:"comments"`
+}
+
+// NewClient creates a client for the pull requests of a repository, given as 'owner/name',
+// on the GitHub REST API at the given base URL
+func NewClient(httpClient *http.Client, baseURL, token, repo string) IGitHub {
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	header.Set("X-GitHub-Api-Version", apiVersion)
+	header.Set("User-Agent", "code-review")
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return &client{
+		api:     &forge.Client{HTTPClient: httpClient, Header: header},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		repo:    repo,
+	}
+}
+
+// ValidateRepo checks that a repository is given as 'owner/name'
+func ValidateRepo(repo string) error {
+	owner, name, found := strings.Cut(repo, "/")
+	if !found || owner == "" || name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("expected the repository as 'owner/name', got %q", repo)
+	}
+	return nil
+}
+
+func (c *client) GetPullRequest(ctx context.Context, number int) (PullRequest, error) {
+	var resp pullRequestResponse
+	if err := c.api.Do(ctx, http.MethodGet, c.repoURL("/pulls/%d", number), nil, &resp); err != nil {
+		return PullRequest{}, fmt.Errorf("failed to get pull request #%d: %v", number, err)
+	}
+	return PullRequest{
+		Number:  resp.Number,
+		Title:   resp.Title,
+		Body:    resp.Body,
+		BaseSHA: resp.Base.SHA,
+		HeadSHA: resp.Head.SHA,
+	}, nil
+}
+
+// GetDiff returns the diff of a pull request against the merge base of its branches, as
+// generated by git
+func (c *client) GetDiff(ctx context.Context, number int) (string, error) {
+	req, err := c.api.NewRequest(ctx, http.MethodGet, c.repoURL("/pulls/%d", number), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github.diff")
+
+	resp, err := c.api.Send(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the diff of pull request #%d: %v", number, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading the diff of pull request #%d: %v", number, err)
+	}
+	return string(data), nil
+}
+
+func (c *client) ListIssueComments(ctx context.Context, number int) ([]Comment, error) {
+	var comments []Comment
+	err := c.api.List(ctx, c.repoURL("/issues/%d/comments", number), func(data []byte) error {
+		var page []Comment
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		comments = append(comments, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the comments of pull request #%d: %v", number, err)
+	}
+	return comments, nil
+}
+
+func (c *client) CreateIssueComment(ctx context.Context, number int, body string) error {
+	if err := c.api.Do(ctx, http.MethodPost, c.repoURL("/issues/%d/comments", number), commentRequest{Body: body}, nil); err != nil {
+		return fmt.Errorf("failed to comment on pull request #%d: %v", number, err)
+	}
+	return nil
+}
+
+func (c *client) UpdateIssueComment(ctx context.Context, id int64, body string) error {
+	if err := c.api.Do(ctx, http.MethodPatch, c.repoURL("/issues/comments/%d", id), commentRequest{Body: body}, nil); err != nil {
+		return fmt.Errorf("failed to update comment %d: %v", id, err)
+	}
+	return nil
+}
+
+func (c *client) ListReviewComments(ctx context.Context, number int) ([]ReviewComment, error) {
+	var comments []ReviewComment
+	err := c.api.List(ctx, c.repoURL("/pulls/%d/comments", number), func(data []byte) error {
+		var page []ReviewComment
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		comments = append(comments, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the review comments of pull request #%d: %v", number, err)
+	}
+	return comments, nil
+}
+
+func (c *client) UpdateReviewComment(ctx context.Context, id int64, body string) error {
+	if err := c.api.Do(ctx, http.MethodPatch, c.repoURL("/pulls/comments/%d", id), commentRequest{Body: body}, nil); err != nil {
+		return fmt.Errorf("failed to update review comment %d: %v", id, err)
+	}
+	return nil
+}
+
+func (c *client) DeleteReviewComment(ctx context.Context, id int64) error {
+	if err := c.api.Do(ctx, http.MethodDelete, c.repoURL("/pulls/comments/%d", id), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete review comment %d: %v", id, err)
+	}
+	return nil
+}
+
+// CreateReview posts a review made of inline comments on the given commit of a pull request
+func (c *client) CreateReview(ctx context.Context, number int, commitID string, comments []DraftComment) error {
+	body := reviewRequest{CommitID: commitID, Event: "COMMENT", Comments: comments}
+	if err := c.api.Do(ctx, http.MethodPost, c.repoURL("/pulls/%d/reviews", number), body, nil); err != nil {
+		return fmt.Errorf("failed to review pull request #%d: %v", number, err)
+	}
+	return nil
+}
+
+// repoURL is the URL of an endpoint of the repository
+func (c *client) repoURL(format string, v ...interface{}) string {
+	return c.baseURL + "/repos/" + c.repo + fmt.Sprintf(format, v...)
+}
@@ -0,0 +1,170 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeGitHub is a stand-in for the pull request endpoints of the GitHub REST API, serving a
+// single pull request of the 'owner/repo' repository
+type fakeGitHub struct {
+	t              *testing.T
+	mu             sync.Mutex
+	nextID         int64
+	diff           string
+	issueComments  []Comment
+	reviewComments []ReviewComment
+	reviews        []reviewRequest
+}
+
+func newFakeGitHub(t *testing.T) (*fakeGitHub, *httptest.Server) {
+	fake := &fakeGitHub{t: t, nextID: 100}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	assert.Equal(f.t, "Bearer token", r.Header.Get("Authorization"))
+	assert.Equal(f.t, apiVersion, r.Header.Get("X-GitHub-Api-Version"))
+
+	path, found := strings.CutPrefix(r.URL.Path, "/api/v3/repos/owner/repo/")
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found"}`))
+		return
+	}
+
+	data, _ := io.ReadAll(r.Body)
+	var body Comment
+	json.Unmarshal(data, &body)
+	id := func(prefix string) int64 {
+		id, _ := strconv.ParseInt(strings.TrimPrefix(path, prefix), 10, 64)
+		return id
+	}
+
+	switch {
+	case r.Method == http.MethodGet && path == "pulls/7":
+		if r.Header.Get("Accept") == "application/vnd.github.diff" {
+			w.Write([]byte(f.diff))
+			return
+		}
+		w.Write([]byte(`{"number":7,"title":"Add F","body":"Adds F","base":{"sha":"base"},"head":{"sha":"head"}}`))
+	case r.Method == http.MethodGet && path == "issues/7/comments":
+		f.page(w, r, f.issueComments)
+	case r.Method == http.MethodPost && path == "issues/7/comments":
+		f.nextID++
+		f.issueComments = append(f.issueComments, Comment{ID: f.nextID, Body: body.Body})
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	case r.Method == http.MethodPatch && strings.HasPrefix(path, "issues/comments/"):
+		for i := range f.issueComments {
+			if f.issueComments[i].ID == id("issues/comments/") {
+				f.issueComments[i].Body = body.Body
+			}
+		}
+		w.Write([]byte(`{}`))
+	case r.Method == http.MethodGet && path == "pulls/7/comments":
+		f.page(w, r, f.reviewComments)
+	case r.Method == http.MethodPatch && strings.HasPrefix(path, "pulls/comments/"):
+		for i := range f.reviewComments {
+			if f.reviewComments[i].ID == id("pulls/comments/") {
+				f.reviewComments[i].Body = body.Body
+			}
+		}
+		w.Write([]byte(`{}`))
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "pulls/comments/"):
+		for i := range f.reviewComments {
+			if f.reviewComments[i].ID == id("pulls/comments/") {
+				f.reviewComments = append(f.reviewComments[:i], f.reviewComments[i+1:]...)
+				break
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && path == "pulls/7/reviews":
+		var review reviewRequest
+		assert.NoError(f.t, json.Unmarshal(data, &review))
+		f.reviews = append(f.reviews, review)
+		for _, comment := range review.Comments {
+			f.nextID++
+			position := comment.Position
+			f.reviewComments = append(f.reviewComments, ReviewComment{ID: f.nextID, Path: comment.Path, Position: &position, Body: comment.Body})
+		}
+		w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message":"Validation Failed"}`))
+	}
+}
+
+// page serves one comment per page, so that clients have to follow the Link headers
+func (f *fakeGitHub) page(w http.ResponseWriter, r *http.Request, comments interface{}) {
+	data, _ := json.Marshal(comments)
+	var all []json.RawMessage
+	json.Unmarshal(data, &all)
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(page, 1)
+	if page < len(all) {
+		next := *r.URL
+		query := next.Query()
+		query.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next", <http://%s/last>; rel="last"`, r.Host, next.String(), r.Host))
+	}
+	if page > len(all) {
+		w.Write([]byte(`[]`))
+		return
+	}
+	data, _ = json.Marshal(all[page-1 : page])
+	w.Write(data)
+}
+
+func TestClient(t *testing.T) {
+	fake, server := newFakeGitHub(t)
+	fake.diff = "diff --git a/a.go b/a.go\n"
+	fake.issueComments = []Comment{{ID: 1, Body: "first"}, {ID: 2, Body: "second"}, {ID: 3, Body: "third"}}
+	client := NewClient(server.Client(), server.URL+"/api/v3/", "token", "owner/repo")
+	ctx := context.Background()
+
+	pr, err := client.GetPullRequest(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, PullRequest{Number: 7, Title: "Add F", Body: "Adds F", BaseSHA: "base", HeadSHA: "head"}, pr)
+
+	rawDiff, err := client.GetDiff(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, fake.diff, rawDiff)
+
+	comments, err := client.ListIssueComments(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, fake.issueComments, comments)
+
+	assert.NoError(t, client.UpdateIssueComment(ctx, 2, "updated"))
+	assert.Equal(t, "updated", fake.issueComments[1].Body)
+
+	_, err = client.GetPullRequest(ctx, 8)
+	assert.EqualError(t, err, "failed to get pull request #8: status 422: Validation Failed")
+
+	_, err = NewClient(server.Client(), server.URL, "token", "owner/repo").GetDiff(ctx, 7)
+	assert.EqualError(t, err, "failed to get the diff of pull request #7: status 404: Not Found")
+}
+
+func TestValidateRepo(t *testing.T) {
+	assert.NoError(t, ValidateRepo("owner/repo"))
+	for _, repo := range []string{"", "repo", "owner/", "/repo", "owner/repo/extra"} {
+		assert.EqualError(t, ValidateRepo(repo), fmt.Sprintf("expected the repository as 'owner/name', got %q", repo))
+	}
+}
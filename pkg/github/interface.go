@@ -0,0 +1,49 @@
+package github
+
+import "context"
+
+type IGitHub interface {
+	GetPullRequest(ctx context.Context, number int) (PullRequest, error)
+	GetDiff(ctx context.Context, number int) (string, error)
+	ListIssueComments(ctx context.Context, number int) ([]Comment, error)
+	CreateIssueComment(ctx context.Context, number int, body string) error
+	UpdateIssueComment(ctx context.Context, id int64, body string) error
+	ListReviewComments(ctx context.Context, number int) ([]ReviewComment, error)
+	UpdateReviewComment(ctx context.Context, id int64, body string) error
+	DeleteReviewComment(ctx context.Context, id int64) error
+	CreateReview(ctx context.Context, number int, commitID string, comments []DraftComment) error
+}
+
+// PullRequest is the part of a pull request needed to review it
+type PullRequest struct {
+	Number int
+	Title  string
+	Body   string
+	// BaseSHA is the commit of the base branch and HeadSHA the last commit of the pull request
+	BaseSHA string
+	HeadSHA string
+}
+
+// Comment is a comment on the conversation of a pull request
+type Comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// ReviewComment is an inline comment on the diff of a pull request
+type ReviewComment struct {
+	ID   int64  `json:"id"`
+	Path string `json:"path"`
+	// Position is the position of the comment in the current diff, nil when the lines it
+	// comments on are no longer part of it
+	Position *int   `json:"position"`
+	Body     string `json:"body"`
+}
+
+// DraftComment is an inline comment of a review that is not posted yet
+type DraftComment struct {
+	Path string `json:"path"`
+	// Position is the position of the line in the diff of the file, see Positions
+	Position int    `json:"position"`
+	Body     string `json:"body"`
+}
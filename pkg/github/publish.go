@@ -0,0 +1,146 @@
+package github
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lmquang/code-review/internal/forge"
+	"github.com/lmquang/code-review/pkg/diff"
+)
+
+// Marker is hidden at the end of the comments posted for a review, so that the next review
+// of the pull request updates them instead of adding more
+const Marker = "<!-- code-review -->"
+
+// PublishResult counts the changes made to the inline comments of a pull request
+type PublishResult struct {
+	Created int
+	Updated int
+	Deleted int
+}
+
+// Positions maps the lines of the new version of a file to their positions in its diff, as
+// GitHub identifies the lines of inline comments. The position of a line is the number of
+// lines below the first hunk header, counting the headers of the following hunks. Deleted
+// lines have no line in the new version and are left out.
+func Positions(hunks []diff.Hunk) map[int]int {
+	positions := make(map[int]int)
+	position := 0
+	for i, hunk := range hunks {
+		if i > 0 {
+			position++
+		}
+		for _, line := range hunk.Lines {
+			position++
+			if line.Kind != diff.LineDeleted {
+				positions[line.NewNumber] = position
+			}
+			if line.NoNewline {
+				position++
+			}
+		}
+	}
+	return positions
+}
+
+// Publish posts a review on a pull request: the summary as a comment on the conversation and
+// the drafts as the inline comments of a review. The comments of the previous review are
+// updated rather than posted again: the summary comment is edited, inline comments on the
+// same lines are edited, and inline comments on lines without a draft anymore are deleted.
+// Inline comments on lines that are no longer part of the diff are left as they are.
+func Publish(ctx context.Context, client IGitHub, pr PullRequest, summary string, drafts []DraftComment) (PublishResult, error) {
+	var result PublishResult
+	if err := publishSummary(ctx, client, pr.Number, summary+"\n\n"+Marker); err != nil {
+		return result, err
+	}
+
+	existing, err := client.ListReviewComments(ctx, pr.Number)
+	if err != nil {
+		return result, err
+	}
+	var previous []ReviewComment
+	byLine := make(map[commentKey]ReviewComment)
+	for _, comment := range existing {
+		if comment.Position == nil || !strings.Contains(comment.Body, Marker) {
+			continue
+		}
+		previous = append(previous, comment)
+		// Only the first comment on a line is kept, in case a review was posted twice
+		key := commentKey{comment.Path, *comment.Position}
+		if _, found := byLine[key]; !found {
+			byLine[key] = comment
+		}
+	}
+
+	kept := make(map[int64]bool)
+	var created []DraftComment
+	// GitHub shows the drafts on a line as separate threads, which later reviews could not tell apart
+	for _, draft := range forge.MergeDrafts(drafts, draftLine, draftBody) {
+		draft.Body += "\n\n" + Marker
+		comment, found := byLine[commentKey{draft.Path, draft.Position}]
+		if !found {
+			created = append(created, draft)
+			continue
+		}
+
+		kept[comment.ID] = true
+		if comment.Body != draft.Body {
+			if err := client.UpdateReviewComment(ctx, comment.ID, draft.Body); err != nil {
+				return result, err
+			}
+			result.Updated++
+		}
+	}
+
+	for _, comment := range previous {
+		if kept[comment.ID] {
+			continue
+		}
+		if err := client.DeleteReviewComment(ctx, comment.ID); err != nil {
+			return result, err
+		}
+		result.Deleted++
+	}
+
+	if len(created) > 0 {
+		if err := client.CreateReview(ctx, pr.Number, pr.HeadSHA, created); err != nil {
+			return result, err
+		}
+		result.Created = len(created)
+	}
+	return result, nil
+}
+
+// commentKey identifies the line an inline comment is on
+type commentKey struct {
+	path     string
+	position int
+}
+
+// publishSummary edits the summary comment of the previous review, or posts one when there
+// is none
+func publishSummary(ctx context.Context, client IGitHub, number int, body string) error {
+	comments, err := client.ListIssueComments(ctx, number)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		if strings.Contains(comment.Body, Marker) {
+			if comment.Body == body {
+				return nil
+			}
+			return client.UpdateIssueComment(ctx, comment.ID, body)
+		}
+	}
+	return client.CreateIssueComment(ctx, number, body)
+}
+
+// draftLine returns the line a draft is on, for merging the drafts on the same line
+func draftLine(draft DraftComment) commentKey {
+	return commentKey{draft.Path, draft.Position}
+}
+
+// draftBody returns the body of a draft, for merging the drafts on the same line
+func draftBody(draft *DraftComment) *string {
+	return &draft.Body
+}
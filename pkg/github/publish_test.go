@@ -0,0 +1,90 @@
+package github
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lmquang/code-review/pkg/diff"
+)
+
+func TestPositions(t *testing.T) {
+	fileDiffs, err := diff.Parse("diff --git a/a.go b/a.go\n" +
+		"--- a/a.go\n" +
+		"+++ b/a.go\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" package a\n" +
+		"-var x = 1\n" +
+		"+var x = 2\n" +
+		" var y = 1\n" +
+		"@@ -10,2 +10,3 @@ func F() {\n" +
+		" \treturn\n" +
+		"+\t// done\n" +
+		" }\n" +
+		"\\ No newline at end of file\n")
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[int]int{1: 1, 2: 3, 3: 4, 10: 6, 11: 7, 12: 8}, Positions(fileDiffs[0].Hunks))
+}
+
+func TestPublish(t *testing.T) {
+	fake, server := newFakeGitHub(t)
+	position := 9
+	fake.reviewComments = []ReviewComment{
+		{ID: 1, Path: "a.go", Position: &position, Body: "a comment of someone else"},
+		{ID: 2, Path: "a.go", Body: "an outdated finding\n\n" + Marker},
+	}
+	client := NewClient(server.Client(), server.URL+"/api/v3", "token", "owner/repo")
+	ctx := context.Background()
+	pr := PullRequest{Number: 7, HeadSHA: "head"}
+
+	result, err := Publish(ctx, client, pr, "First review", []DraftComment{
+		{Path: "a.go", Position: 3, Body: "x changed"},
+		{Path: "a.go", Position: 7, Body: "a comment"},
+		{Path: "a.go", Position: 7, Body: "another comment"},
+		{Path: "b.go", Position: 1, Body: "fixed later"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, PublishResult{Created: 3}, result)
+	assert.Equal(t, []Comment{{ID: 101, Body: "First review\n\n" + Marker}}, fake.issueComments)
+	assert.Equal(t, []reviewRequest{{CommitID: "head", Event: "COMMENT", Comments: []DraftComment{
+		{Path: "a.go", Position: 3, Body: "x changed\n\n" + Marker},
+		{Path: "a.go", Position: 7, Body: "a comment\n\n---\n\nanother comment\n\n" + Marker},
+		{Path: "b.go", Position: 1, Body: "fixed later\n\n" + Marker},
+	}}}, fake.reviews)
+
+	pr.HeadSHA = "new-head"
+	result, err = Publish(ctx, client, pr, "Second review", []DraftComment{
+		{Path: "a.go", Position: 3, Body: "x changed"},
+		{Path: "a.go", Position: 7, Body: "a better comment"},
+		{Path: "c.go", Position: 2, Body: "new"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, PublishResult{Created: 1, Updated: 1, Deleted: 1}, result)
+	assert.Equal(t, []Comment{{ID: 101, Body: "Second review\n\n" + Marker}}, fake.issueComments)
+	assert.Len(t, fake.reviews, 2)
+	assert.Equal(t, reviewRequest{CommitID: "new-head", Event: "COMMENT", Comments: []DraftComment{
+		{Path: "c.go", Position: 2, Body: "new\n\n" + Marker},
+	}}, fake.reviews[1])
+
+	three, seven, two := 3, 7, 2
+	assert.Equal(t, []ReviewComment{
+		{ID: 1, Path: "a.go", Position: &position, Body: "a comment of someone else"},
+		{ID: 2, Path: "a.go", Body: "an outdated finding\n\n" + Marker},
+		{ID: 102, Path: "a.go", Position: &three, Body: "x changed\n\n" + Marker},
+		{ID: 103, Path: "a.go", Position: &seven, Body: "a better comment\n\n" + Marker},
+		{ID: 105, Path: "c.go", Position: &two, Body: "new\n\n" + Marker},
+	}, fake.reviewComments)
+
+	// Publishing the same review again changes nothing
+	result, err = Publish(ctx, client, pr, "Second review", []DraftComment{
+		{Path: "a.go", Position: 3, Body: "x changed"},
+		{Path: "a.go", Position: 7, Body: "a better comment"},
+		{Path: "c.go", Position: 2, Body: "new"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, PublishResult{}, result)
+	assert.Len(t, fake.reviews, 2)
+	assert.Len(t, fake.reviewComments, 5)
+}
@@ -0,0 +1,215 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lmquang/code-review/internal/forge"
+)
+
+const (
+	// DefaultBaseURL is the endpoint of the REST API of GitLab.com. Self-managed instances
+	// serve it at https://HOST/api/v4.
+	DefaultBaseURL = "https://gitlab.com/api/v4"
+)
+
+type client struct {
+	api     *forge.Client
+	baseURL string
+	project string
+}
+
+type mergeRequestResponse struct {
+	IID         int    `json:"iid"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	DiffRefs    struct {
+		BaseSHA  string `json:"base_sha"`
+		StartSHA string `json:"start_sha"`
+		HeadSHA  string `json:"head_sha"`
+	} `json:"diff_refs"`
+}
+
+// fileDiff is the diff of a single file, whose hunks come without the git headers
+type fileDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	AMode       string `json:"a_mode"`
+	BMode       string `json:"b_mode"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+type noteRequest struct {
+	Body string `json:"body"`
+}
+
+type discussionRequest struct {
+	Body     string   `json:"body"`
+	Position Position `json:"position"`
+}
+
+// NewClient creates a client for the merge requests of a project, given by its ID or its
+// full path such as 'group/project', on the GitLab REST API at the given base URL
+func NewClient(httpClient *http.Client, baseURL, token, project string) IGitLab {
+	header := http.Header{}
+	if token != "" {
+		header.Set("PRIVATE-TOKEN", token)
+	}
+	return &client{
+		api:     &forge.Client{HTTPClient: httpClient, Header: header},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		project: project,
+	}
+}
+
+func (c *client) GetMergeRequest(ctx context.Context, iid int) (MergeRequest, error) {
+	var resp mergeRequestResponse
+	if err := c.api.Do(ctx, http.MethodGet, c.projectURL("/merge_requests/%d", iid), nil, &resp); err != nil {
+		return MergeRequest{}, fmt.Errorf("failed to get merge request !%d: %v", iid, err)
+	}
+	return MergeRequest{
+		IID:         resp.IID,
+		Title:       resp.Title,
+		Description: resp.Description,
+		BaseSHA:     resp.DiffRefs.BaseSHA,
+		StartSHA:    resp.DiffRefs.StartSHA,
+		HeadSHA:     resp.DiffRefs.HeadSHA,
+	}, nil
+}
+
+// GetDiff returns the diff of the latest version of a merge request, rebuilt in the format
+// of git from the diffs of its files
+func (c *client) GetDiff(ctx context.Context, iid int) (string, error) {
+	var sb strings.Builder
+	err := c.api.List(ctx, c.projectURL("/merge_requests/%d/diffs", iid), func(data []byte) error {
+		var page []fileDiff
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		for _, file := range page {
+			sb.WriteString(gitDiff(file))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the diff of merge request !%d: %v", iid, err)
+	}
+	return sb.String(), nil
+}
+
+func (c *client) ListNotes(ctx context.Context, iid int) ([]Note, error) {
+	var notes []Note
+	err := c.api.List(ctx, c.projectURL("/merge_requests/%d/notes", iid), func(data []byte) error {
+		var page []Note
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		notes = append(notes, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the notes of merge request !%d: %v", iid, err)
+	}
+	return notes, nil
+}
+
+func (c *client) CreateNote(ctx context.Context, iid int, body string) error {
+	if err := c.api.Do(ctx, http.MethodPost, c.projectURL("/merge_requests/%d/notes", iid), noteRequest{Body: body}, nil); err != nil {
+		return fmt.Errorf("failed to comment on merge request !%d: %v", iid, err)
+	}
+	return nil
+}
+
+func (c *client) UpdateNote(ctx context.Context, iid int, id int64, body string) error {
+	if err := c.api.Do(ctx, http.MethodPut, c.projectURL("/merge_requests/%d/notes/%d", iid, id), noteRequest{Body: body}, nil); err != nil {
+		return fmt.Errorf("failed to update note %d: %v", id, err)
+	}
+	return nil
+}
+
+func (c *client) ListDiscussions(ctx context.Context, iid int) ([]Discussion, error) {
+	var discussions []Discussion
+	err := c.api.List(ctx, c.projectURL("/merge_requests/%d/discussions", iid), func(data []byte) error {
+		var page []Discussion
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		discussions = append(discussions, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the discussions of merge request !%d: %v", iid, err)
+	}
+	return discussions, nil
+}
+
+// CreateDiscussion starts a discussion on a line of the diff of a merge request
+func (c *client) CreateDiscussion(ctx context.Context, iid int, body string, position Position) error {
+	request := discussionRequest{Body: body, Position: position}
+	if err := c.api.Do(ctx, http.MethodPost, c.projectURL("/merge_requests/%d/discussions", iid), request, nil); err != nil {
+		return fmt.Errorf("failed to start a discussion on %s line %d of merge request !%d: %v", position.NewPath, position.NewLine, iid, err)
+	}
+	return nil
+}
+
+func (c *client) UpdateDiscussionNote(ctx context.Context, iid int, discussionID string, noteID int64, body string) error {
+	endpoint := c.projectURL("/merge_requests/%d/discussions/%s/notes/%d", iid, url.PathEscape(discussionID), noteID)
+	if err := c.api.Do(ctx, http.MethodPut, endpoint, noteRequest{Body: body}, nil); err != nil {
+		return fmt.Errorf("failed to update note %d: %v", noteID, err)
+	}
+	return nil
+}
+
+func (c *client) ResolveDiscussion(ctx context.Context, iid int, discussionID string) error {
+	endpoint := c.projectURL("/merge_requests/%d/discussions/%s", iid, url.PathEscape(discussionID)) + "?resolved=true"
+	if err := c.api.Do(ctx, http.MethodPut, endpoint, nil, nil); err != nil {
+		return fmt.Errorf("failed to resolve discussion %s: %v", discussionID, err)
+	}
+	return nil
+}
+
+// gitDiff rebuilds the diff of a file in the format of git, with the headers the hunks of
+// the API come without
+func gitDiff(file fileDiff) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", file.OldPath, file.NewPath))
+	oldPath, newPath := "a/"+file.OldPath, "b/"+file.NewPath
+	switch {
+	case file.NewFile:
+		sb.WriteString(fmt.Sprintf("new file mode %s\n", file.BMode))
+		oldPath = "/dev/null"
+	case file.DeletedFile:
+		sb.WriteString(fmt.Sprintf("deleted file mode %s\n", file.AMode))
+		newPath = "/dev/null"
+	case file.AMode != file.BMode:
+		sb.WriteString(fmt.Sprintf("old mode %s\nnew mode %s\n", file.AMode, file.BMode))
+	}
+	if file.RenamedFile {
+		sb.WriteString(fmt.Sprintf("rename from %s\nrename to %s\n", file.OldPath, file.NewPath))
+	}
+
+	switch {
+	case file.Diff == "":
+	case strings.HasPrefix(file.Diff, "Binary files "):
+		sb.WriteString(file.Diff)
+	default:
+		sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldPath, newPath))
+		sb.WriteString(file.Diff)
+	}
+	if file.Diff != "" && !strings.HasSuffix(file.Diff, "\n") {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// projectURL is the URL of an endpoint of the project
+func (c *client) projectURL(format string, v ...interface{}) string {
+	return c.baseURL + "/projects/" + url.PathEscape(c.project) + fmt.Sprintf(format, v...)
+}
@@ -0,0 +1,175 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lmquang/code-review/pkg/diff"
+)
+
+// fakeGitLab is a stand-in for the merge request endpoints of the GitLab REST API, serving a
+// single merge request of the 'group/project' project
+type fakeGitLab struct {
+	t           *testing.T
+	mu          sync.Mutex
+	nextID      int64
+	diffs       []fileDiff
+	notes       []Note
+	discussions []Discussion
+}
+
+func newFakeGitLab(t *testing.T) (*fakeGitLab, *httptest.Server) {
+	fake := &fakeGitLab{t: t, nextID: 100}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeGitLab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	assert.Equal(f.t, "token", r.Header.Get("PRIVATE-TOKEN"))
+
+	path, found := strings.CutPrefix(r.URL.EscapedPath(), "/api/v4/projects/group%2Fproject/merge_requests/3")
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"404 Project Not Found"}`))
+		return
+	}
+
+	data, _ := io.ReadAll(r.Body)
+	var body discussionRequest
+	json.Unmarshal(data, &body)
+
+	switch {
+	case r.Method == http.MethodGet && path == "":
+		w.Write([]byte(`{"iid":3,"title":"Add F","description":"Adds F","diff_refs":{"base_sha":"base","start_sha":"start","head_sha":"head"}}`))
+	case r.Method == http.MethodGet && path == "/diffs":
+		f.page(w, r, f.diffs)
+	case r.Method == http.MethodGet && path == "/notes":
+		f.page(w, r, f.notes)
+	case r.Method == http.MethodPost && path == "/notes":
+		f.nextID++
+		f.notes = append(f.notes, Note{ID: f.nextID, Body: body.Body})
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "/notes/"):
+		for i := range f.notes {
+			if strconv.FormatInt(f.notes[i].ID, 10) == strings.TrimPrefix(path, "/notes/") {
+				f.notes[i].Body = body.Body
+			}
+		}
+		w.Write([]byte(`{}`))
+	case r.Method == http.MethodGet && path == "/discussions":
+		f.page(w, r, f.discussions)
+	case r.Method == http.MethodPost && path == "/discussions":
+		if body.Position.NewLine > 20 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":{"base":["line_code can't be blank"]}}`))
+			return
+		}
+		f.nextID++
+		position := body.Position
+		f.discussions = append(f.discussions, Discussion{
+			ID:    fmt.Sprintf("d%d", f.nextID),
+			Notes: []Note{{ID: f.nextID, Body: body.Body, Position: &position}},
+		})
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "/discussions/"):
+		discussionID, noteID, isNote := strings.Cut(strings.TrimPrefix(path, "/discussions/"), "/notes/")
+		for i := range f.discussions {
+			if f.discussions[i].ID != discussionID {
+				continue
+			}
+			if isNote {
+				assert.Equal(f.t, strconv.FormatInt(f.discussions[i].Notes[0].ID, 10), noteID)
+				f.discussions[i].Notes[0].Body = body.Body
+			} else {
+				assert.Equal(f.t, "true", r.URL.Query().Get("resolved"))
+				f.discussions[i].Notes[0].Resolved = true
+			}
+		}
+		w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write([]byte(`{"error":"405 Method Not Allowed"}`))
+	}
+}
+
+// page serves one item per page, so that clients have to follow the Link headers
+func (f *fakeGitLab) page(w http.ResponseWriter, r *http.Request, items interface{}) {
+	data, _ := json.Marshal(items)
+	var all []json.RawMessage
+	json.Unmarshal(data, &all)
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(page, 1)
+	if page < len(all) {
+		next := *r.URL
+		query := next.Query()
+		query.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next", <http://%s/first>; rel="first"`, r.Host, next.String(), r.Host))
+	}
+	if page > len(all) {
+		w.Write([]byte(`[]`))
+		return
+	}
+	data, _ = json.Marshal(all[page-1 : page])
+	w.Write(data)
+}
+
+func TestClient(t *testing.T) {
+	fake, server := newFakeGitLab(t)
+	fake.diffs = []fileDiff{
+		{OldPath: "a.go", NewPath: "a.go", AMode: "100644", BMode: "100644", Diff: "@@ -1 +1,2 @@\n package a\n+func F() {}\n"},
+		{OldPath: "new.go", NewPath: "new.go", AMode: "0", BMode: "100644", NewFile: true, Diff: "@@ -0,0 +1 @@\n+package a"},
+		{OldPath: "old.go", NewPath: "moved.go", AMode: "100644", BMode: "100755", RenamedFile: true},
+		{OldPath: "gone.go", NewPath: "gone.go", AMode: "100644", BMode: "0", DeletedFile: true, Diff: "@@ -1 +0,0 @@\n-package a\n"},
+		{OldPath: "logo.png", NewPath: "logo.png", AMode: "100644", BMode: "100644", Diff: "Binary files a/logo.png and b/logo.png differ\n"},
+	}
+	client := NewClient(server.Client(), server.URL+"/api/v4/", "token", "group/project")
+	ctx := context.Background()
+
+	mr, err := client.GetMergeRequest(ctx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, MergeRequest{IID: 3, Title: "Add F", Description: "Adds F", BaseSHA: "base", StartSHA: "start", HeadSHA: "head"}, mr)
+
+	rawDiff, err := client.GetDiff(ctx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1,2 @@\n package a\n+func F() {}\n"+
+		"diff --git a/new.go b/new.go\nnew file mode 100644\n--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+package a\n"+
+		"diff --git a/old.go b/moved.go\nold mode 100644\nnew mode 100755\nrename from old.go\nrename to moved.go\n"+
+		"diff --git a/gone.go b/gone.go\ndeleted file mode 100644\n--- a/gone.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-package a\n"+
+		"diff --git a/logo.png b/logo.png\nBinary files a/logo.png and b/logo.png differ\n", rawDiff)
+
+	fileDiffs, err := diff.Parse(rawDiff)
+	assert.NoError(t, err)
+	var types []diff.ChangeType
+	for _, fileDiff := range fileDiffs {
+		types = append(types, fileDiff.Type)
+	}
+	assert.Equal(t, []diff.ChangeType{diff.ChangeModified, diff.ChangeAdded, diff.ChangeRenamed, diff.ChangeDeleted, diff.ChangeModified}, types)
+	assert.True(t, fileDiffs[4].Binary)
+
+	_, err = client.GetMergeRequest(ctx, 4)
+	assert.EqualError(t, err, "failed to get merge request !4: status 404: 404 Project Not Found")
+
+	err = client.CreateDiscussion(ctx, 3, "far", Position{NewPath: "a.go", NewLine: 40})
+	assert.EqualError(t, err, `failed to start a discussion on a.go line 40 of merge request !3: status 400: {"base":["line_code can't be blank"]}`)
+
+	err = client.ResolveDiscussion(ctx, 4, "d1")
+	assert.EqualError(t, err, "failed to resolve discussion d1: status 404: 404 Project Not Found")
+}
@@ -0,0 +1,61 @@
+package gitlab
+
+import "context"
+
+type IGitLab interface {
+	GetMergeRequest(ctx context.Context, iid int) (MergeRequest, error)
+	GetDiff(ctx context.Context, iid int) (string, error)
+	ListNotes(ctx context.Context, iid int) ([]Note, error)
+	CreateNote(ctx context.Context, iid int, body string) error
+	UpdateNote(ctx context.Context, iid int, id int64, body string) error
+	ListDiscussions(ctx context.Context, iid int) ([]Discussion, error)
+	CreateDiscussion(ctx context.Context, iid int, body string, position Position) error
+	UpdateDiscussionNote(ctx context.Context, iid int, discussionID string, noteID int64, body string) error
+	ResolveDiscussion(ctx context.Context, iid int, discussionID string) error
+}
+
+// MergeRequest is the part of a merge request needed to review it
+type MergeRequest struct {
+	IID         int
+	Title       string
+	Description string
+	// BaseSHA, StartSHA and HeadSHA are the diff refs of the latest version of the merge
+	// request, which locate discussions on its diff
+	BaseSHA  string
+	StartSHA string
+	HeadSHA  string
+}
+
+// Note is a comment on a merge request, either on its own or in a discussion
+type Note struct {
+	ID       int64     `json:"id"`
+	Body     string    `json:"body"`
+	System   bool      `json:"system"`
+	Resolved bool      `json:"resolved"`
+	Position *Position `json:"position"`
+}
+
+// Discussion is a thread of notes, started on a line of the diff for diff discussions
+type Discussion struct {
+	ID    string `json:"id"`
+	Notes []Note `json:"notes"`
+}
+
+// Position is the location of a diff discussion. Added lines have only a new line, deleted
+// lines only an old line, and unchanged lines both.
+type Position struct {
+	PositionType string `json:"position_type"`
+	BaseSHA      string `json:"base_sha"`
+	StartSHA     string `json:"start_sha"`
+	HeadSHA      string `json:"head_sha"`
+	OldPath      string `json:"old_path"`
+	NewPath      string `json:"new_path"`
+	OldLine      int    `json:"old_line,omitempty"`
+	NewLine      int    `json:"new_line,omitempty"`
+}
+
+// DraftDiscussion is a diff discussion that is not posted yet
+type DraftDiscussion struct {
+	Position Position
+	Body     string
+}
@@ -0,0 +1,151 @@
+package gitlab
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lmquang/code-review/internal/forge"
+	"github.com/lmquang/code-review/pkg/diff"
+)
+
+// Marker is hidden at the end of the notes posted for a review, so that the next review of
+// the merge request updates them instead of adding more
+const Marker = "<!-- code-review -->"
+
+// PublishResult counts the changes made to the diff discussions of a merge request
+type PublishResult struct {
+	Created  int
+	Updated  int
+	Resolved int
+}
+
+// LinePosition locates a line of the new version of a file in the hunks of its diff in a merge
+// request. The old path is empty for added files. It returns false when the line is not shown
+// in the diff.
+func LinePosition(mr MergeRequest, oldPath, newPath string, hunks []diff.Hunk, line int) (Position, bool) {
+	position := Position{
+		PositionType: "text",
+		BaseSHA:      mr.BaseSHA,
+		StartSHA:     mr.StartSHA,
+		HeadSHA:      mr.HeadSHA,
+		OldPath:      oldPath,
+		NewPath:      newPath,
+	}
+	// Added files have no old path, but discussions on them still need one
+	if position.OldPath == "" {
+		position.OldPath = position.NewPath
+	}
+
+	for _, hunk := range hunks {
+		for _, l := range hunk.Lines {
+			if l.Kind == diff.LineDeleted || l.NewNumber != line {
+				continue
+			}
+			position.NewLine = l.NewNumber
+			if l.Kind == diff.LineContext {
+				position.OldLine = l.OldNumber
+			}
+			return position, true
+		}
+	}
+	return Position{}, false
+}
+
+// Publish posts a review on a merge request: the summary as a note and the drafts as diff
+// discussions. The notes of the previous review are updated rather than posted again: the
+// summary note is edited, unresolved discussions on the same lines are edited, and the
+// discussions on lines without a draft anymore are resolved. Resolved discussions are left as
+// they are, so a finding on their line starts a new discussion.
+func Publish(ctx context.Context, client IGitLab, mr MergeRequest, summary string, drafts []DraftDiscussion) (PublishResult, error) {
+	var result PublishResult
+	if err := publishSummary(ctx, client, mr.IID, summary+"\n\n"+Marker); err != nil {
+		return result, err
+	}
+
+	discussions, err := client.ListDiscussions(ctx, mr.IID)
+	if err != nil {
+		return result, err
+	}
+	var previous []Discussion
+	byLine := make(map[lineKey]Discussion)
+	for _, discussion := range discussions {
+		if len(discussion.Notes) == 0 {
+			continue
+		}
+		first := discussion.Notes[0]
+		if first.Position == nil || first.Resolved || !strings.Contains(first.Body, Marker) {
+			continue
+		}
+		previous = append(previous, discussion)
+		// Only the first discussion on a line is kept, in case a review was posted twice
+		key := lineKey{first.Position.NewPath, first.Position.NewLine}
+		if _, found := byLine[key]; !found {
+			byLine[key] = discussion
+		}
+	}
+
+	kept := make(map[string]bool)
+	for _, draft := range forge.MergeDrafts(drafts, draftLine, draftBody) {
+		draft.Body += "\n\n" + Marker
+		discussion, found := byLine[lineKey{draft.Position.NewPath, draft.Position.NewLine}]
+		if !found {
+			if err := client.CreateDiscussion(ctx, mr.IID, draft.Body, draft.Position); err != nil {
+				return result, err
+			}
+			result.Created++
+			continue
+		}
+
+		kept[discussion.ID] = true
+		if note := discussion.Notes[0]; note.Body != draft.Body {
+			if err := client.UpdateDiscussionNote(ctx, mr.IID, discussion.ID, note.ID, draft.Body); err != nil {
+				return result, err
+			}
+			result.Updated++
+		}
+	}
+
+	for _, discussion := range previous {
+		if kept[discussion.ID] {
+			continue
+		}
+		if err := client.ResolveDiscussion(ctx, mr.IID, discussion.ID); err != nil {
+			return result, err
+		}
+		result.Resolved++
+	}
+	return result, nil
+}
+
+// lineKey identifies the line of the new version of a file a discussion is on
+type lineKey struct {
+	path string
+	line int
+}
+
+// publishSummary edits the summary note of the previous review, or posts one when there is none
+func publishSummary(ctx context.Context, client IGitLab, iid int, body string) error {
+	notes, err := client.ListNotes(ctx, iid)
+	if err != nil {
+		return err
+	}
+	for _, note := range notes {
+		if note.Position == nil && !note.System && strings.Contains(note.Body, Marker) {
+			if note.Body == body {
+				return nil
+			}
+			return client.UpdateNote(ctx, iid, note.ID, body)
+		}
+	}
+	return client.CreateNote(ctx, iid, body)
+}
+
+// draftLine is the line of the new version of a file a draft discussion is on
+func draftLine(draft DraftDiscussion) lineKey {
+	return lineKey{draft.Position.NewPath, draft.Position.NewLine}
+}
+
+// draftBody points at the body of a draft discussion, which the drafts merged into it are appended to
+func draftBody(draft *DraftDiscussion) *string {
+	return &draft.Body
+}
@@ -0,0 +1,100 @@
+package gitlab
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lmquang/code-review/pkg/diff"
+)
+
+func TestLinePosition(t *testing.T) {
+	fileDiffs, err := diff.Parse("diff --git a/a.go b/b.go\n" +
+		"rename from a.go\n" +
+		"rename to b.go\n" +
+		"--- a/a.go\n" +
+		"+++ b/b.go\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" package a\n" +
+		"-var x = 1\n" +
+		"+var x = 2\n" +
+		" var y = 1\n" +
+		"diff --git a/new.go b/new.go\n" +
+		"new file mode 100644\n" +
+		"--- /dev/null\n" +
+		"+++ b/new.go\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+package a\n")
+	assert.NoError(t, err)
+	mr := MergeRequest{BaseSHA: "base", StartSHA: "start", HeadSHA: "head"}
+	position := Position{PositionType: "text", BaseSHA: "base", StartSHA: "start", HeadSHA: "head", OldPath: "a.go", NewPath: "b.go"}
+
+	got, found := LinePosition(mr, fileDiffs[0].OldPath, fileDiffs[0].NewPath, fileDiffs[0].Hunks, 2)
+	assert.True(t, found)
+	added := position
+	added.NewLine = 2
+	assert.Equal(t, added, got)
+
+	got, found = LinePosition(mr, fileDiffs[0].OldPath, fileDiffs[0].NewPath, fileDiffs[0].Hunks, 3)
+	assert.True(t, found)
+	unchanged := position
+	unchanged.OldLine, unchanged.NewLine = 3, 3
+	assert.Equal(t, unchanged, got)
+
+	_, found = LinePosition(mr, fileDiffs[0].OldPath, fileDiffs[0].NewPath, fileDiffs[0].Hunks, 4)
+	assert.False(t, found)
+
+	got, found = LinePosition(mr, fileDiffs[1].OldPath, fileDiffs[1].NewPath, fileDiffs[1].Hunks, 1)
+	assert.True(t, found)
+	assert.Equal(t, Position{PositionType: "text", BaseSHA: "base", StartSHA: "start", HeadSHA: "head", OldPath: "new.go", NewPath: "new.go", NewLine: 1}, got)
+}
+
+func TestPublish(t *testing.T) {
+	fake, server := newFakeGitLab(t)
+	fake.notes = []Note{{ID: 1, Body: "Set as draft", System: true}}
+	fake.discussions = []Discussion{
+		{ID: "d1", Notes: []Note{{ID: 1, Body: "a question of someone else", Position: &Position{NewPath: "a.go", NewLine: 3}}}},
+		{ID: "d2", Notes: []Note{{ID: 2, Body: "a fixed finding\n\n" + Marker, Resolved: true, Position: &Position{NewPath: "a.go", NewLine: 7}}}},
+	}
+	client := NewClient(server.Client(), server.URL+"/api/v4", "token", "group/project")
+	ctx := context.Background()
+	mr := MergeRequest{IID: 3, BaseSHA: "base", StartSHA: "start", HeadSHA: "head"}
+	at := func(path string, line int) Position {
+		return Position{PositionType: "text", BaseSHA: "base", StartSHA: "start", HeadSHA: "head", OldPath: path, NewPath: path, NewLine: line}
+	}
+
+	result, err := Publish(ctx, client, mr, "First review", []DraftDiscussion{
+		{Position: at("a.go", 3), Body: "x changed"},
+		{Position: at("a.go", 7), Body: "a comment"},
+		{Position: at("a.go", 7), Body: "another comment"},
+		{Position: at("b.go", 1), Body: "fixed later"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, PublishResult{Created: 3}, result)
+	assert.Equal(t, []Note{{ID: 1, Body: "Set as draft", System: true}, {ID: 101, Body: "First review\n\n" + Marker}}, fake.notes)
+	assert.Len(t, fake.discussions, 5)
+	assert.Equal(t, "a comment\n\n---\n\nanother comment\n\n"+Marker, fake.discussions[3].Notes[0].Body)
+	assert.Equal(t, at("a.go", 7), *fake.discussions[3].Notes[0].Position)
+
+	result, err = Publish(ctx, client, mr, "Second review", []DraftDiscussion{
+		{Position: at("a.go", 3), Body: "x changed"},
+		{Position: at("a.go", 7), Body: "a better comment"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, PublishResult{Updated: 1, Resolved: 1}, result)
+	assert.Equal(t, "Second review\n\n"+Marker, fake.notes[1].Body)
+	assert.Equal(t, "x changed\n\n"+Marker, fake.discussions[2].Notes[0].Body)
+	assert.Equal(t, "a better comment\n\n"+Marker, fake.discussions[3].Notes[0].Body)
+	assert.True(t, fake.discussions[4].Notes[0].Resolved)
+	assert.False(t, fake.discussions[0].Notes[0].Resolved)
+
+	// Publishing the same review again changes nothing
+	result, err = Publish(ctx, client, mr, "Second review", []DraftDiscussion{
+		{Position: at("a.go", 3), Body: "x changed"},
+		{Position: at("a.go", 7), Body: "a better comment"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, PublishResult{}, result)
+	assert.Len(t, fake.discussions, 5)
+}
@@ -0,0 +1,215 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lmquang/code-review/pkg/gpt/provider"
+)
+
+const (
+	// DefaultBaseURL is the endpoint of the Anthropic API
+	DefaultBaseURL = "https://api.anthropic.com"
+	// DefaultModel is the model used when none is configured
+	DefaultModel = "claude-3-5-haiku-latest"
+	// apiVersion is the version of the Messages API the requests are written for
+	apiVersion = "2023-06-01"
+	// defaultMaxTokens is sent when a request has no limit, as the Messages API requires one
+	defaultMaxTokens = 1024
+)
+
+type anthropic struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type messagesRequest struct {
+	Model       string    `json:"model"`
+	System      string    `json:"system,omitempty"`
+	Messages    []message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	TopP        *float64  `json:"top_p,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+}
+
+type messagesResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+}
+
+// streamEvent is an event of a streamed answer. Text arrives in content_block_delta events
+// and the stop reason in a message_delta event.
+type streamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewAnthropic creates a provider for the Anthropic Messages API at the given base URL
+func NewAnthropic(httpClient *http.Client, baseURL, apiKey, model string) provider.IProvider {
+	return &anthropic{
+		httpClient: httpClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+	}
+}
+
+func (c *anthropic) Name() string {
+	return provider.Anthropic
+}
+
+func (c *anthropic) SetModel(model string) {
+	c.model = model
+}
+
+func (c *anthropic) GetModel() string {
+	return c.model
+}
+
+// Chat sends the request to the Messages API
+func (c *anthropic) Chat(ctx context.Context, request provider.ChatRequest) (provider.ChatResponse, error) {
+	var resp messagesResponse
+	if err := provider.PostJSON(ctx, c.httpClient, c.baseURL+"/v1/messages", c.headers(), messagesBody(request), &resp, errorMessage); err != nil {
+		return provider.ChatResponse{}, err
+	}
+
+	var content strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+	return provider.ChatResponse{
+		Content:      content.String(),
+		FinishReason: finishReason(resp.StopReason),
+	}, nil
+}
+
+// ChatStream streams the answer as server-sent events, calling onDelta with every piece of
+// it as it arrives, and returns the complete answer
+func (c *anthropic) ChatStream(ctx context.Context, request provider.ChatRequest, onDelta func(delta string)) (provider.ChatResponse, error) {
+	body := messagesBody(request)
+	body.Stream = true
+
+	stream, err := provider.PostStream(ctx, c.httpClient, c.baseURL+"/v1/messages", c.headers(), body, errorMessage)
+	if err != nil {
+		return provider.ChatResponse{}, err
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var stopReason string
+	err = provider.ScanLines(stream, func(line string) error {
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			return nil
+		}
+
+		var event streamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("error decoding event: %v", err)
+		}
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				content.WriteString(event.Delta.Text)
+				onDelta(event.Delta.Text)
+			}
+		case "message_delta":
+			stopReason = event.Delta.StopReason
+		case "error":
+			return fmt.Errorf("%s: %s", event.Error.Type, event.Error.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		return provider.ChatResponse{}, err
+	}
+
+	return provider.ChatResponse{
+		Content:      content.String(),
+		FinishReason: finishReason(stopReason),
+	}, nil
+}
+
+func (c *anthropic) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": apiVersion,
+	}
+}
+
+// messagesBody converts a provider-neutral request to a Messages API request. System
+// messages are combined into the system prompt, which the API takes separately from the
+// conversation.
+func messagesBody(request provider.ChatRequest) messagesRequest {
+	// The Messages API has no seed, and reasoning is configured as a token budget instead
+	body := messagesRequest{
+		Model:       request.Model,
+		MaxTokens:   request.MaxTokens,
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+	}
+	if body.MaxTokens == 0 {
+		body.MaxTokens = defaultMaxTokens
+	}
+
+	var system []string
+	for _, m := range request.Messages {
+		if m.Role == provider.RoleSystem {
+			system = append(system, m.Content)
+			continue
+		}
+		body.Messages = append(body.Messages, message{Role: string(m.Role), Content: m.Content})
+	}
+	body.System = strings.Join(system, "\n\n")
+	return body
+}
+
+// finishReason maps a stop reason of the Messages API to a provider-neutral finish reason
+func finishReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return provider.FinishStop
+	case "max_tokens":
+		return provider.FinishLength
+	default:
+		return stopReason
+	}
+}
+
+// errorMessage extracts the message of an error response of the Anthropic API
+func errorMessage(body []byte) string {
+	var resp struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error.Message == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s: %s", resp.Error.Type, resp.Error.Message)
+}
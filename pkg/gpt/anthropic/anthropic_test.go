@@ -0,0 +1,101 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lmquang/code-review/pkg/gpt/provider"
+)
+
+func TestAnthropic_Chat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, apiVersion, r.Header.Get("anthropic-version"))
+
+		var body messagesRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body.Model == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"type":"error","error":{"type":"not_found_error","message":"model: missing"}}`))
+			return
+		}
+
+		assert.Equal(t, messagesRequest{
+			Model:     "claude",
+			System:    "Review the diff",
+			Messages:  []message{{Role: "user", Content: "diff"}},
+			MaxTokens: 1000,
+		}, body)
+		w.Write([]byte(`{"content":[{"type":"text","text":"<review>"},{"type":"text","text":"</review>"}],"stop_reason":"max_tokens"}`))
+	}))
+	defer server.Close()
+
+	client := NewAnthropic(server.Client(), server.URL+"/", "test-key", "claude")
+	request := provider.ChatRequest{
+		Model: "claude",
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "Review the diff"},
+			{Role: provider.RoleUser, Content: "diff"},
+		},
+		MaxTokens: 1000,
+	}
+
+	resp, err := client.Chat(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, provider.ChatResponse{Content: "<review></review>", FinishReason: provider.FinishLength}, resp)
+
+	request.Model = "missing"
+	_, err = client.Chat(context.Background(), request)
+	assert.EqualError(t, err, "status 404: not_found_error: model: missing")
+}
+
+func TestFinishReason(t *testing.T) {
+	assert.Equal(t, provider.FinishStop, finishReason("end_turn"))
+	assert.Equal(t, provider.FinishStop, finishReason("stop_sequence"))
+	assert.Equal(t, provider.FinishLength, finishReason("max_tokens"))
+	assert.Equal(t, "refusal", finishReason("refusal"))
+}
+
+func TestAnthropic_ChatStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body messagesRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.True(t, body.Stream)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{}}\n\n" +
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"<review>\"}}\n\n" +
+			"event: ping\ndata: {\"type\":\"ping\"}\n\n" +
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"</review>\"}}\n\n" +
+			"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"}}\n\n" +
+			"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+	}))
+	defer server.Close()
+
+	client := NewAnthropic(server.Client(), server.URL, "test-key", "claude")
+	var deltas []string
+	resp, err := client.ChatStream(context.Background(), provider.ChatRequest{Model: "claude"}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"<review>", "</review>"}, deltas)
+	assert.Equal(t, provider.ChatResponse{Content: "<review></review>", FinishReason: provider.FinishStop}, resp)
+}
+
+func TestAnthropic_ChatStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"))
+	}))
+	defer server.Close()
+
+	client := NewAnthropic(server.Client(), server.URL, "test-key", "claude")
+	_, err := client.ChatStream(context.Background(), provider.ChatRequest{Model: "claude"}, func(string) {})
+	assert.EqualError(t, err, "overloaded_error: Overloaded")
+}
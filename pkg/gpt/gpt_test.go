@@ -1,7 +1,10 @@
 package gpt
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -9,8 +12,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
-	mocksgpt "github.com/lmquang/code-review/mocks/pkg/gpt"
-	mocksgptopenai "github.com/lmquang/code-review/mocks/pkg/gpt/openai"
+	mockscache "github.com/lmquang/code-review/mocks/pkg/cache"
+	mocksprovider "github.com/lmquang/code-review/mocks/pkg/gpt/provider"
+	"github.com/lmquang/code-review/pkg/gpt/provider"
 )
 
 func TestNewOpenAIClient(t *testing.T) {
@@ -25,7 +29,8 @@ func TestGPT_Review(t *testing.T) {
 		name            string
 		originalContent string
 		formattedDiff   string
-		mockResponse    openai.ChatCompletionResponse
+		opts            ReviewOptions
+		mockResponse    provider.ChatResponse
 		mockError       error
 		expectedError   error
 	}{
@@ -33,14 +38,8 @@ func TestGPT_Review(t *testing.T) {
 			name:            "Successful review",
 			originalContent: "<original-content><file path=\"file.txt\">Line 1\nLine 2\nLine 3</file></original-content>",
 			formattedDiff:   "<git-diff><file><n>file.txt</n><changes><![CDATA[diff --git a/file.txt b/file.txt\nindex 1234567..890abcd 100644\n--- a/file.txt\n+++ b/file.txt\n@@ -1,3 +1,4 @@\n Line 1\n-Line 2\n+Updated Line 2\n Line 3\n+New Line 4]]></changes></file></git-diff>",
-			mockResponse: openai.ChatCompletionResponse{
-				Choices: []openai.ChatCompletionChoice{
-					{
-						Message: openai.ChatCompletionMessage{
-							Content: "<review><style_and_conventions>Style is consistent.</style_and_conventions><comments_review>No comments added.</comments_review><best_practices>Code follows best practices.</best_practices><summary>Changes look good.</summary></review>",
-						},
-					},
-				},
+			mockResponse: provider.ChatResponse{
+				Content: "<review><style_and_conventions>Style is consistent.</style_and_conventions><comments_review>No comments added.</comments_review><best_practices>Code follows best practices.</best_practices><summary>Changes look good.</summary></review>",
 			},
 			mockError:     nil,
 			expectedError: nil,
@@ -49,22 +48,27 @@ func TestGPT_Review(t *testing.T) {
 			name:            "Error during review",
 			originalContent: "<original-content><file path=\"file.txt\">Original content</file></original-content>",
 			formattedDiff:   "<git-diff><file><n>file.txt</n><changes><![CDATA[Sample diff]]></changes></file></git-diff>",
-			mockResponse:    openai.ChatCompletionResponse{},
+			mockResponse:    provider.ChatResponse{},
 			mockError:       errors.New("OpenAI API error"),
 			expectedError:   errors.New("ChatCompletion error: OpenAI API error"),
 		},
+		{
+			name:            "Review with commit messages",
+			originalContent: "<original-content><file path=\"file.txt\">Original content</file></original-content>",
+			formattedDiff:   "<git-diff><file><n>file.txt</n><changes><![CDATA[Sample diff]]></changes></file></git-diff>",
+			opts:            ReviewOptions{CommitMessages: []string{"Fix typo in file.txt"}},
+			mockResponse: provider.ChatResponse{
+				Content: "<review><summary>The change matches the commit message.</summary></review>",
+			},
+			mockError:     nil,
+			expectedError: nil,
+		},
 		{
 			name:            "Empty formatted diff",
 			originalContent: "<original-content></original-content>",
 			formattedDiff:   "<git-diff></git-diff>",
-			mockResponse: openai.ChatCompletionResponse{
-				Choices: []openai.ChatCompletionChoice{
-					{
-						Message: openai.ChatCompletionMessage{
-							Content: "<review><summary>No changes detected in the diff.</summary></review>",
-						},
-					},
-				},
+			mockResponse: provider.ChatResponse{
+				Content: "<review><summary>No changes detected in the diff.</summary></review>",
 			},
 			mockError:     nil,
 			expectedError: nil,
@@ -73,77 +77,466 @@ func TestGPT_Review(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockOpenAI := new(mocksgptopenai.IOpenAI)
-			mockOpenAI.On("CreateChatCompletion", mock.Anything, mock.MatchedBy(func(req openai.ChatCompletionRequest) bool {
+			mockProvider := new(mocksprovider.IProvider)
+			mockProvider.On("Chat", mock.Anything, mock.MatchedBy(func(req provider.ChatRequest) bool {
 				return req.MaxTokens == 1000 && req.Model == openai.GPT4oMini &&
 					strings.Contains(req.Messages[0].Content, tt.originalContent) &&
+					strings.Contains(req.Messages[0].Content, "<commit-messages>") == (len(tt.opts.CommitMessages) > 0) &&
 					req.Messages[1].Content == tt.formattedDiff
 			})).Return(tt.mockResponse, tt.mockError)
-			mockOpenAI.On("GetModel").Return(openai.GPT4oMini)
+			mockProvider.On("GetModel").Return(openai.GPT4oMini)
+			mockProvider.On("Name").Return(provider.OpenAI)
 
 			gpt := &gpt{
-				client: mockOpenAI,
+				client: mockProvider,
 			}
 
-			result, err := gpt.Review(tt.originalContent, tt.formattedDiff)
+			result, err := gpt.Review(context.Background(), tt.originalContent, tt.formattedDiff, tt.opts)
 
 			if tt.expectedError != nil {
 				assert.Error(t, err)
 				assert.Equal(t, tt.expectedError.Error(), err.Error())
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.mockResponse.Choices[0].Message.Content, result)
+				assert.Equal(t, tt.mockResponse.Content, result)
 			}
 
-			mockOpenAI.AssertExpectations(t)
+			mockProvider.AssertExpectations(t)
 		})
 	}
 }
 
-func TestGPT_Client(t *testing.T) {
-	mockOpenAI := new(mocksgptopenai.IOpenAI)
+func TestGPT_Merge(t *testing.T) {
+	mockProvider := new(mocksprovider.IProvider)
+	mockProvider.On("Chat", mock.Anything, mock.MatchedBy(func(req provider.ChatRequest) bool {
+		return strings.Contains(req.Messages[0].Content, "consolidating code reviews") &&
+			strings.Contains(req.Messages[0].Content, "Split the parser") &&
+			req.Messages[1].Content == "<batch-reviews>\n"+
+				"<batch-review index=\"1\">\n<review>first</review>\n</batch-review>\n"+
+				"<batch-review index=\"2\">\n<review>second</review>\n</batch-review>\n"+
+				"</batch-reviews>"
+	})).Return(provider.ChatResponse{
+		Content: "<review>merged</review>",
+	}, nil)
+	mockProvider.On("GetModel").Return(openai.GPT4oMini)
+	mockProvider.On("Name").Return(provider.OpenAI)
+
 	gpt := &gpt{
-		client: mockOpenAI,
+		client: mockProvider,
 	}
 
-	assert.Equal(t, mockOpenAI, gpt.Client())
+	result, err := gpt.Merge(context.Background(), []string{"<review>first</review>", "<review>second</review>"}, ReviewOptions{CommitMessages: []string{"Split the parser"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "<review>merged</review>", result)
+	mockProvider.AssertExpectations(t)
 }
 
-func TestGPT_ImplementsIGPT(t *testing.T) {
-	client := NewOpenAIClient("test-api-key")
-	assert.Implements(t, (*IGPT)(nil), client)
+func TestGPT_Repair(t *testing.T) {
+	mockProvider := new(mocksprovider.IProvider)
+	mockProvider.On("Chat", mock.Anything, mock.MatchedBy(func(request provider.ChatRequest) bool {
+		return strings.Contains(request.Messages[0].Content, "could not be parsed: the review has no <findings> element.") &&
+			strings.Contains(request.Messages[0].Content, reviewFormat) &&
+			request.Messages[1].Content == "<review><summary>s</summary></review>"
+	})).Return(provider.ChatResponse{Content: "<review><summary>s</summary><findings></findings></review>"}, nil)
+	mockProvider.On("GetModel").Return(openai.GPT4oMini)
+	mockProvider.On("Name").Return(provider.OpenAI)
+
+	gpt := &gpt{
+		client: mockProvider,
+	}
+
+	result, err := gpt.Repair(context.Background(), "<review><summary>s</summary></review>", "the review has no <findings> element")
+	assert.NoError(t, err)
+	assert.Equal(t, "<review><summary>s</summary><findings></findings></review>", result)
+	assert.Contains(t, reviewPrompt("", ReviewOptions{}), reviewFormat)
 }
 
-func TestMockIGPT(t *testing.T) {
-	mockGPT := new(mocksgpt.IGPT)
-	mockOpenAI := new(mocksgptopenai.IOpenAI)
+func TestGPT_SetRateLimit(t *testing.T) {
+	mockProvider := new(mocksprovider.IProvider)
+	mockProvider.On("Chat", mock.Anything, mock.Anything).Return(provider.ChatResponse{
+		Content: "<review></review>",
+	}, nil)
+	mockProvider.On("GetModel").Return(openai.GPT4oMini)
+	mockProvider.On("Name").Return(provider.OpenAI)
+
+	gpt := &gpt{
+		client: mockProvider,
+	}
+	gpt.SetRateLimit(60, 1000000)
+	assert.NotNil(t, gpt.limiter)
+
+	_, err := gpt.Review(context.Background(), "<original-content></original-content>", "<git-diff></git-diff>", ReviewOptions{})
+	assert.NoError(t, err)
+	mockProvider.AssertExpectations(t)
+}
+
+func TestGPT_SetCache(t *testing.T) {
+	t.Run("Stores new answers", func(t *testing.T) {
+		mockProvider := new(mocksprovider.IProvider)
+		mockProvider.On("Chat", mock.Anything, mock.Anything).Return(provider.ChatResponse{
+			Content: "<review>fresh</review>",
+		}, nil)
+		mockProvider.On("GetModel").Return(openai.GPT4oMini)
+		mockProvider.On("Name").Return(provider.OpenAI)
+		mockCache := new(mockscache.ICache)
+		mockCache.On("Get", mock.AnythingOfType("string")).Return("", false, nil)
+		mockCache.On("Put", mock.AnythingOfType("string"), "<review>fresh</review>").Return(nil)
+
+		gpt := &gpt{
+			client: mockProvider,
+		}
+		gpt.SetCache(mockCache)
 
-	originalContent := "<original-content><file path=\"file.txt\">Original content</file></original-content>"
-	formattedDiff := "<git-diff><file><n>file.txt</n><changes><![CDATA[Sample diff]]></changes></file></git-diff>"
+		result, err := gpt.Review(context.Background(), "<original-content></original-content>", "<git-diff></git-diff>", ReviewOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "<review>fresh</review>", result)
+		mockProvider.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
 
-	mockGPT.On("Review", originalContent, formattedDiff).Return("<review><summary>Mock review</summary></review>", nil)
-	mockGPT.On("Client").Return(mockOpenAI)
+	t.Run("Reuses cached answers", func(t *testing.T) {
+		mockProvider := new(mocksprovider.IProvider)
+		mockProvider.On("GetModel").Return(openai.GPT4oMini)
+		mockProvider.On("Name").Return(provider.OpenAI)
+		mockCache := new(mockscache.ICache)
+		mockCache.On("Get", mock.AnythingOfType("string")).Return("<review>cached</review>", true, nil)
 
-	result, err := mockGPT.Review(originalContent, formattedDiff)
+		gpt := &gpt{
+			client: mockProvider,
+		}
+		gpt.SetCache(mockCache)
+
+		result, err := gpt.Review(context.Background(), "<original-content></original-content>", "<git-diff></git-diff>", ReviewOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "<review>cached</review>", result)
+		mockProvider.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("Keys depend on the request", func(t *testing.T) {
+		mockProvider := new(mocksprovider.IProvider)
+		mockProvider.On("GetModel").Return(openai.GPT4oMini)
+		mockProvider.On("Name").Return(provider.OpenAI)
+
+		gpt := &gpt{
+			client: mockProvider,
+			cache:  new(mockscache.ICache),
+		}
+		request := provider.ChatRequest{Model: openai.GPT4oMini, Messages: []provider.Message{{Content: "prompt"}}}
+
+		assert.Equal(t, gpt.cacheKey(request, []string{"a"}), gpt.cacheKey(request, []string{"a"}))
+		assert.NotEqual(t, gpt.cacheKey(request, []string{"a"}), gpt.cacheKey(request, []string{"b"}))
+		assert.NotEqual(t, gpt.cacheKey(request, []string{"a", "b"}), gpt.cacheKey(request, []string{"ab"}))
+
+		other := request
+		other.Model = openai.GPT4o
+		assert.NotEqual(t, gpt.cacheKey(request, []string{"a"}), gpt.cacheKey(other, []string{"a"}))
+		other = request
+		other.MaxTokens = 100
+		assert.NotEqual(t, gpt.cacheKey(request, []string{"a"}), gpt.cacheKey(other, []string{"a"}))
+
+		otherProvider := new(mocksprovider.IProvider)
+		otherProvider.On("Name").Return(provider.Ollama)
+		key := gpt.cacheKey(request, []string{"a"})
+		gpt.client = otherProvider
+		assert.NotEqual(t, key, gpt.cacheKey(request, []string{"a"}))
+
+		gpt.cache = nil
+		assert.Empty(t, gpt.cacheKey(request, []string{"a"}))
+	})
+
+	t.Run("Review keys leave out the commit messages", func(t *testing.T) {
+		mockProvider := new(mocksprovider.IProvider)
+		mockProvider.On("GetModel").Return(openai.GPT4oMini)
+		mockProvider.On("Name").Return(provider.OpenAI)
+		var keys []string
+		mockCache := new(mockscache.ICache)
+		mockCache.On("Get", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+			keys = append(keys, args.String(0))
+		}).Return("<review>cached</review>", true, nil)
+
+		gpt := &gpt{
+			client: mockProvider,
+		}
+		gpt.SetCache(mockCache)
+
+		ctx := context.Background()
+		for _, opts := range []ReviewOptions{
+			{CommitMessages: []string{"Add feature"}},
+			{CommitMessages: []string{"Add feature", "fixup! Add feature"}},
+			{CommitMessages: []string{"Add feature"}, PreviousReview: "<review>earlier</review>"},
+		} {
+			_, err := gpt.Review(ctx, "<original-content></original-content>", "<git-diff></git-diff>", opts)
+			assert.NoError(t, err)
+		}
+		_, err := gpt.Review(ctx, "<original-content></original-content>", "<git-diff>changed</git-diff>", ReviewOptions{})
+		assert.NoError(t, err)
+
+		assert.Len(t, keys, 4)
+		assert.Equal(t, keys[0], keys[1])
+		assert.NotEqual(t, keys[0], keys[2])
+		assert.NotEqual(t, keys[0], keys[3])
+	})
+}
+
+func TestGPT_ReviewStream(t *testing.T) {
+	t.Run("Streams the answer", func(t *testing.T) {
+		mockProvider := new(mocksprovider.IProvider)
+		mockProvider.On("ChatStream", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			onDelta := args.Get(2).(func(string))
+			onDelta("<review>")
+			onDelta("</review>")
+		}).Return(provider.ChatResponse{Content: "<review></review>"}, nil)
+		mockProvider.On("GetModel").Return(openai.GPT4oMini)
+		mockProvider.On("Name").Return(provider.OpenAI)
+
+		gpt := &gpt{
+			client: mockProvider,
+		}
+
+		var stream strings.Builder
+		result, err := gpt.Review(context.Background(), "<original-content></original-content>", "<git-diff></git-diff>", ReviewOptions{Stream: &stream})
+		assert.NoError(t, err)
+		assert.Equal(t, "<review></review>", result)
+		assert.Equal(t, "<review></review>", stream.String())
+		mockProvider.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Writes cached answers at once", func(t *testing.T) {
+		mockProvider := new(mocksprovider.IProvider)
+		mockProvider.On("GetModel").Return(openai.GPT4oMini)
+		mockProvider.On("Name").Return(provider.OpenAI)
+		mockCache := new(mockscache.ICache)
+		mockCache.On("Get", mock.AnythingOfType("string")).Return("<review>cached</review>", true, nil)
+
+		gpt := &gpt{
+			client: mockProvider,
+			cache:  mockCache,
+		}
+
+		var stream strings.Builder
+		result, err := gpt.Review(context.Background(), "<original-content></original-content>", "<git-diff></git-diff>", ReviewOptions{Stream: &stream})
+		assert.NoError(t, err)
+		assert.Equal(t, "<review>cached</review>", result)
+		assert.Equal(t, "<review>cached</review>", stream.String())
+		mockProvider.AssertNotCalled(t, "ChatStream", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGPT_SetGeneration(t *testing.T) {
+	temperature, seed := 0.2, 42
+	mockProvider := new(mocksprovider.IProvider)
+	mockProvider.On("Chat", mock.Anything, mock.MatchedBy(func(request provider.ChatRequest) bool {
+		return request.MaxTokens == 4000 && *request.Temperature == 0.2 && request.TopP == nil &&
+			*request.Seed == 42 && request.ReasoningEffort == "high"
+	})).Return(provider.ChatResponse{Content: "<review></review>", FinishReason: provider.FinishStop}, nil)
+	mockProvider.On("GetModel").Return("o3-mini")
+	mockProvider.On("Name").Return(provider.OpenAI)
+
+	gpt := &gpt{
+		client: mockProvider,
+	}
+	gpt.SetGeneration(GenerationOptions{MaxTokens: 4000, Temperature: &temperature, Seed: &seed, ReasoningEffort: "high"})
+
+	result, err := gpt.Review(context.Background(), "", "<git-diff></git-diff>", ReviewOptions{})
 	assert.NoError(t, err)
-	assert.True(t, strings.Contains(result, "Mock review"))
+	assert.Equal(t, "<review></review>", result)
+	mockProvider.AssertExpectations(t)
+}
+
+func TestGPT_ReviewContinuation(t *testing.T) {
+	t.Run("Continues answers cut off at the maximum number of tokens", func(t *testing.T) {
+		mockProvider := new(mocksprovider.IProvider)
+		mockProvider.On("ChatStream", mock.Anything, mock.MatchedBy(func(request provider.ChatRequest) bool {
+			return len(request.Messages) == 2
+		}), mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(2).(func(string))("<review><issue>cut")
+		}).Return(provider.ChatResponse{Content: "<review><issue>cut", FinishReason: provider.FinishLength}, nil).Once()
+		mockProvider.On("ChatStream", mock.Anything, mock.MatchedBy(func(request provider.ChatRequest) bool {
+			return len(request.Messages) == 4 &&
+				request.Messages[2] == provider.Message{Role: provider.RoleAssistant, Content: "<review><issue>cut"} &&
+				request.Messages[3] == provider.Message{Role: provider.RoleUser, Content: continuePrompt}
+		}), mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(2).(func(string))(" off</issue></review>")
+		}).Return(provider.ChatResponse{Content: " off</issue></review>", FinishReason: provider.FinishStop}, nil).Once()
+		mockProvider.On("GetModel").Return(openai.GPT4oMini)
+		mockProvider.On("Name").Return(provider.OpenAI)
+		mockCache := new(mockscache.ICache)
+		mockCache.On("Get", mock.AnythingOfType("string")).Return("", false, nil)
+		mockCache.On("Put", mock.AnythingOfType("string"), "<review><issue>cut off</issue></review>").Return(nil)
 
-	client := mockGPT.Client()
-	assert.Equal(t, mockOpenAI, client)
+		gpt := &gpt{
+			client: mockProvider,
+			cache:  mockCache,
+		}
 
-	mockGPT.AssertExpectations(t)
+		var stream strings.Builder
+		result, err := gpt.Review(context.Background(), "", "<git-diff></git-diff>", ReviewOptions{Stream: &stream})
+		assert.NoError(t, err)
+		assert.Equal(t, "<review><issue>cut off</issue></review>", result)
+		assert.Equal(t, result, stream.String())
+		mockProvider.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("Stops after the maximum number of continuations", func(t *testing.T) {
+		mockProvider := new(mocksprovider.IProvider)
+		mockProvider.On("Chat", mock.Anything, mock.Anything).Return(provider.ChatResponse{Content: "x", FinishReason: provider.FinishLength}, nil)
+		mockProvider.On("GetModel").Return(openai.GPT4oMini)
+		mockProvider.On("Name").Return(provider.OpenAI)
+
+		gpt := &gpt{
+			client: mockProvider,
+		}
+
+		result, err := gpt.Review(context.Background(), "", "<git-diff></git-diff>", ReviewOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Repeat("x", maxContinuations+1), result)
+		mockProvider.AssertNumberOfCalls(t, "Chat", maxContinuations+1)
+	})
+
+	t.Run("Fails when the limit is reached before any answer", func(t *testing.T) {
+		mockProvider := new(mocksprovider.IProvider)
+		mockProvider.On("Chat", mock.Anything, mock.Anything).Return(provider.ChatResponse{FinishReason: provider.FinishLength}, nil)
+		mockProvider.On("GetModel").Return("o3-mini")
+		mockProvider.On("Name").Return(provider.OpenAI)
+
+		gpt := &gpt{
+			client: mockProvider,
+		}
+
+		_, err := gpt.Review(context.Background(), "", "<git-diff></git-diff>", ReviewOptions{})
+		assert.EqualError(t, err, "the limit of 1000 tokens was reached before any answer was generated, please raise -max-tokens")
+		mockProvider.AssertNumberOfCalls(t, "Chat", 1)
+	})
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          ProviderOptions
+		expectedName  string
+		expectedModel string
+		expectedError string
+	}{
+		{
+			name:          "Defaults to OpenAI",
+			opts:          ProviderOptions{APIKey: "key"},
+			expectedName:  provider.OpenAI,
+			expectedModel: openai.GPT4oMini,
+		},
+		{
+			name:          "Azure deployment",
+			opts:          ProviderOptions{Provider: provider.Azure, APIKey: "key", Model: "reviews", BaseURL: "https://example.openai.azure.com"},
+			expectedName:  provider.Azure,
+			expectedModel: "reviews",
+		},
+		{
+			name:          "Azure without endpoint",
+			opts:          ProviderOptions{Provider: provider.Azure, APIKey: "key", Model: "reviews"},
+			expectedError: "the azure provider requires the endpoint of the resource",
+		},
+		{
+			name:          "Anthropic",
+			opts:          ProviderOptions{Provider: provider.Anthropic, APIKey: "key"},
+			expectedName:  provider.Anthropic,
+			expectedModel: "claude-3-5-haiku-latest",
+		},
+		{
+			name:          "Ollama with model",
+			opts:          ProviderOptions{Provider: provider.Ollama, Model: "qwen2.5-coder"},
+			expectedName:  provider.Ollama,
+			expectedModel: "qwen2.5-coder",
+		},
+		{
+			name:          "Unknown provider",
+			opts:          ProviderOptions{Provider: "other"},
+			expectedError: `unknown provider "other", expected "openai", "azure", "anthropic" or "ollama"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, err := NewProvider(tt.opts)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedName, chat.Name())
+			assert.Equal(t, tt.expectedModel, chat.GetModel())
+		})
+	}
+}
+
+func TestNewProvider_OpenAICompatible(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"))
+		assert.Equal(t, "team", r.Header.Get("OpenAI-Organization"))
+		assert.Equal(t, "gateway-token", r.Header.Get("X-Gateway-Key"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"<review></review>"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	chat, err := NewProvider(ProviderOptions{
+		Model:        "local",
+		BaseURL:      server.URL + "/v1/",
+		Organization: "team",
+		Headers:      map[string]string{"X-Gateway-Key": "gateway-token"},
+	})
+	assert.NoError(t, err)
+
+	resp, err := chat.Chat(context.Background(), provider.ChatRequest{Model: "local", Messages: []provider.Message{{Role: provider.RoleUser, Content: "diff"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "<review></review>", resp.Content)
+}
+
+func TestReviewPrompt_PreviousReview(t *testing.T) {
+	opts := ReviewOptions{PreviousReview: "<review>Rename foo</review>"}
+
+	assert.NotContains(t, reviewPrompt("", ReviewOptions{}), "<previous-review>")
+	assert.Contains(t, reviewPrompt("", opts), "<previous-review><![CDATA[<review>Rename foo</review>]]></previous-review>")
+	assert.Contains(t, reviewPrompt("", opts), "<previous_findings>")
+	assert.NotContains(t, mergePrompt(ReviewOptions{}), "<previous_findings>")
+	assert.Contains(t, mergePrompt(opts), "<previous_findings>")
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens(""))
+	assert.Equal(t, 1, EstimateTokens("abc"))
+	assert.Equal(t, 2, EstimateTokens("abcdefgh"))
+	assert.Equal(t, 1, EstimateTokens("ü"))
+	assert.Greater(t, ReviewPromptTokens(ReviewOptions{CommitMessages: []string{"message"}}), ReviewPromptTokens(ReviewOptions{}))
+}
+
+func TestGPT_Client(t *testing.T) {
+	mockProvider := new(mocksprovider.IProvider)
+	gpt := &gpt{
+		client: mockProvider,
+	}
+
+	assert.Equal(t, mockProvider, gpt.Client())
+}
+
+func TestGPT_ImplementsIGPT(t *testing.T) {
+	client := NewOpenAIClient("test-api-key")
+	assert.Implements(t, (*IGPT)(nil), client)
 }
 
 func TestOpenAIClient_GetModel(t *testing.T) {
-	mockOpenAI := new(mocksgptopenai.IOpenAI)
-	mockOpenAI.On("GetModel").Return(openai.GPT4oMini)
+	mockProvider := new(mocksprovider.IProvider)
+	mockProvider.On("GetModel").Return(openai.GPT4oMini)
 
 	gpt := &gpt{
-		client: mockOpenAI,
+		client: mockProvider,
 	}
 
 	model := gpt.Client().GetModel()
 	assert.Equal(t, openai.GPT4oMini, model)
 
-	mockOpenAI.AssertExpectations(t)
+	mockProvider.AssertExpectations(t)
 }
@@ -1,14 +1,52 @@
 package gpt
 
 import (
-	gptopenai "github.com/lmquang/code-review/pkg/gpt/openai"
+	"context"
+	"io"
+
+	"github.com/lmquang/code-review/pkg/cache"
+	"github.com/lmquang/code-review/pkg/gpt/provider"
+	"github.com/lmquang/code-review/pkg/ratelimit"
 )
 
 type IGPT interface {
-	Review(originalContent, formattedDiff string) (string, error)
-	Client() gptopenai.IOpenAI
+	Review(ctx context.Context, originalContent, formattedDiff string, opts ReviewOptions) (string, error)
+	Merge(ctx context.Context, reviews []string, opts ReviewOptions) (string, error)
+	Repair(ctx context.Context, review, problem string) (string, error)
+	SetRateLimit(requestsPerMinute, tokensPerMinute int)
+	SetCache(answers cache.ICache)
+	SetGeneration(opts GenerationOptions)
+	Client() provider.IProvider
+}
+
+// ReviewOptions carries optional context about the changes under review
+type ReviewOptions struct {
+	// CommitMessages are the messages of the commits that introduced the changes
+	CommitMessages []string
+	// PreviousReview is the review of the branch before these changes were added, whose
+	// findings are checked against the changes
+	PreviousReview string
+	// Stream receives the answer as it is generated when set, or at once when it is cached
+	Stream io.Writer
+}
+
+// GenerationOptions controls how the provider generates answers
+type GenerationOptions struct {
+	// MaxTokens is the maximum number of tokens of each answer, DefaultMaxTokens when zero.
+	// Answers cut off at this limit are continued in further requests.
+	MaxTokens int
+	// Temperature, TopP and Seed are left to the provider's defaults when nil
+	Temperature *float64
+	TopP        *float64
+	Seed        *int
+	// ReasoningEffort is how long reasoning models think before answering, such as "low",
+	// "medium" or "high"
+	ReasoningEffort string
 }
 
 type gpt struct {
-	client gptopenai.IOpenAI
+	client     provider.IProvider
+	limiter    *ratelimit.Limiter
+	cache      cache.ICache
+	generation GenerationOptions
 }
@@ -0,0 +1,34 @@
+package gpt_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	mocksgpt "github.com/lmquang/code-review/mocks/pkg/gpt"
+	mocksprovider "github.com/lmquang/code-review/mocks/pkg/gpt/provider"
+	"github.com/lmquang/code-review/pkg/gpt"
+)
+
+func TestMockIGPT(t *testing.T) {
+	mockGPT := new(mocksgpt.IGPT)
+	mockProvider := new(mocksprovider.IProvider)
+
+	originalContent := "<original-content><file path=\"file.txt\">Original content</file></original-content>"
+	formattedDiff := "<git-diff><file><n>file.txt</n><changes><![CDATA[Sample diff]]></changes></file></git-diff>"
+
+	mockGPT.On("Review", mock.Anything, originalContent, formattedDiff, gpt.ReviewOptions{}).Return("<review><summary>Mock review</summary></review>", nil)
+	mockGPT.On("Client").Return(mockProvider)
+
+	result, err := mockGPT.Review(context.Background(), originalContent, formattedDiff, gpt.ReviewOptions{})
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(result, "Mock review"))
+
+	client := mockGPT.Client()
+	assert.Equal(t, mockProvider, client)
+
+	mockGPT.AssertExpectations(t)
+}
@@ -0,0 +1,152 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lmquang/code-review/pkg/gpt/provider"
+)
+
+const (
+	// DefaultBaseURL is the endpoint of a local Ollama server
+	DefaultBaseURL = "http://localhost:11434"
+	// DefaultModel is the model used when none is configured
+	DefaultModel = "llama3.1"
+)
+
+type ollama struct {
+	httpClient *http.Client
+	baseURL    string
+	model      string
+}
+
+type chatRequest struct {
+	Model    string             `json:"model"`
+	Messages []provider.Message `json:"messages"`
+	Stream   bool               `json:"stream"`
+	Options  *chatOptions       `json:"options,omitempty"`
+}
+
+type chatOptions struct {
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+}
+
+type chatResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	DoneReason string `json:"done_reason"`
+	// Error is set on a line of a stream that failed
+	Error string `json:"error"`
+}
+
+// NewOllama creates a provider for the chat API of an Ollama server at the given base URL
+func NewOllama(httpClient *http.Client, baseURL, model string) provider.IProvider {
+	return &ollama{
+		httpClient: httpClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		model:      model,
+	}
+}
+
+func (c *ollama) Name() string {
+	return provider.Ollama
+}
+
+func (c *ollama) SetModel(model string) {
+	c.model = model
+}
+
+func (c *ollama) GetModel() string {
+	return c.model
+}
+
+func (c *ollama) Chat(ctx context.Context, request provider.ChatRequest) (provider.ChatResponse, error) {
+	var resp chatResponse
+	if err := provider.PostJSON(ctx, c.httpClient, c.baseURL+"/api/chat", nil, chatBody(request, false), &resp, errorMessage); err != nil {
+		return provider.ChatResponse{}, err
+	}
+	return provider.ChatResponse{
+		Content:      resp.Message.Content,
+		FinishReason: resp.DoneReason,
+	}, nil
+}
+
+// errorMessage extracts the message of an error response of the Ollama API
+func errorMessage(body []byte) string {
+	var resp struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ""
+	}
+	return resp.Error
+}
+
+// ChatStream streams the answer as one JSON object per line, calling onDelta with every piece
+// of it as it arrives, and returns the complete answer
+func (c *ollama) ChatStream(ctx context.Context, request provider.ChatRequest, onDelta func(delta string)) (provider.ChatResponse, error) {
+	stream, err := provider.PostStream(ctx, c.httpClient, c.baseURL+"/api/chat", nil, chatBody(request, true), errorMessage)
+	if err != nil {
+		return provider.ChatResponse{}, err
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var doneReason string
+	err = provider.ScanLines(stream, func(line string) error {
+		if strings.TrimSpace(line) == "" {
+			return nil
+		}
+
+		var resp chatResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			return fmt.Errorf("error decoding stream: %v", err)
+		}
+		if resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+		if delta := resp.Message.Content; delta != "" {
+			content.WriteString(delta)
+			onDelta(delta)
+		}
+		if resp.DoneReason != "" {
+			doneReason = resp.DoneReason
+		}
+		return nil
+	})
+	if err != nil {
+		return provider.ChatResponse{}, err
+	}
+
+	return provider.ChatResponse{
+		Content:      content.String(),
+		FinishReason: doneReason,
+	}, nil
+}
+
+// chatBody converts a provider-neutral request to a request of the Ollama chat API
+func chatBody(request provider.ChatRequest, stream bool) chatRequest {
+	body := chatRequest{
+		Model:    request.Model,
+		Messages: request.Messages,
+		Stream:   stream,
+	}
+	options := chatOptions{
+		NumPredict:  request.MaxTokens,
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+		Seed:        request.Seed,
+	}
+	if options != (chatOptions{}) {
+		body.Options = &options
+	}
+	return body
+}
@@ -0,0 +1,87 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lmquang/code-review/pkg/gpt/provider"
+)
+
+func TestOllama_Chat(t *testing.T) {
+	temperature, seed := 0.0, 7
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+
+		var body chatRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body.Model == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+			return
+		}
+
+		assert.Equal(t, chatRequest{
+			Model: "llama3.1",
+			Messages: []provider.Message{
+				{Role: provider.RoleSystem, Content: "Review the diff"},
+				{Role: provider.RoleUser, Content: "diff"},
+			},
+			Options: &chatOptions{NumPredict: 1000, Temperature: &temperature, Seed: &seed},
+		}, body)
+		w.Write([]byte(`{"model":"llama3.1","message":{"role":"assistant","content":"<review></review>"},"done":true,"done_reason":"stop"}`))
+	}))
+	defer server.Close()
+
+	client := NewOllama(server.Client(), server.URL, "llama3.1")
+	request := provider.ChatRequest{
+		Model: "llama3.1",
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "Review the diff"},
+			{Role: provider.RoleUser, Content: "diff"},
+		},
+		MaxTokens:   1000,
+		Temperature: &temperature,
+		Seed:        &seed,
+	}
+
+	resp, err := client.Chat(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, provider.ChatResponse{Content: "<review></review>", FinishReason: provider.FinishStop}, resp)
+
+	request.Model = "missing"
+	_, err = client.Chat(context.Background(), request)
+	assert.EqualError(t, err, `status 404: model "missing" not found, try pulling it first`)
+}
+
+func TestOllama_ChatStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body chatRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.True(t, body.Stream)
+		if body.Model == "failing" {
+			w.Write([]byte(`{"message":{"content":"<rev"},"done":false}` + "\n" + `{"error":"model crashed"}` + "\n"))
+			return
+		}
+		w.Write([]byte(`{"message":{"content":"<review>"},"done":false}` + "\n" +
+			`{"message":{"content":"</review>"},"done":false}` + "\n" +
+			`{"message":{"content":""},"done":true,"done_reason":"length"}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := NewOllama(server.Client(), server.URL, "llama3.1")
+	var deltas []string
+	resp, err := client.ChatStream(context.Background(), provider.ChatRequest{Model: "llama3.1"}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"<review>", "</review>"}, deltas)
+	assert.Equal(t, provider.ChatResponse{Content: "<review></review>", FinishReason: provider.FinishLength}, resp)
+
+	_, err = client.ChatStream(context.Background(), provider.ChatRequest{Model: "failing"}, func(string) {})
+	assert.EqualError(t, err, "model crashed")
+}
@@ -2,32 +2,233 @@ package gpt
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"strings"
 
 	"github.com/sashabaranov/go-openai"
 
+	"github.com/lmquang/code-review/pkg/cache"
 	gptopenai "github.com/lmquang/code-review/pkg/gpt/openai"
+	"github.com/lmquang/code-review/pkg/gpt/provider"
+	"github.com/lmquang/code-review/pkg/ratelimit"
 )
 
+// PromptVersion identifies the prompts and the expected format of the answers. Bump it when
+// they change in a way that makes previously cached answers unusable.
+const PromptVersion = "4"
+
+// maxContinuations is the number of times an answer cut off at the maximum number of tokens
+// is continued before it is returned incomplete
+const maxContinuations = 5
+
+// continuePrompt asks for the rest of an answer that was cut off
+const continuePrompt = "Your answer was cut off. Continue exactly where it stopped, without repeating anything or adding an introduction."
+
 // NewOpenAIClient creates a new GPT client
 func NewOpenAIClient(apiKey string) IGPT {
+	return NewClient(gptopenai.NewOpenAI(openai.NewClient(apiKey), openai.GPT4oMini))
+}
+
+// NewClient creates a GPT client reviewing through the given chat provider
+func NewClient(chat provider.IProvider) IGPT {
 	return &gpt{
-		client: gptopenai.NewOpenAI(openai.NewClient(apiKey), openai.GPT4oMini),
+		client: chat,
 	}
 }
 
-func (c *gpt) Client() gptopenai.IOpenAI {
+func (c *gpt) Client() provider.IProvider {
 	return c.client
 }
 
-// Review sends the original content and formatted diff to GPT for review
-func (c *gpt) Review(originalContent, formattedDiff string) (string, error) {
+// Review sends the original content and formatted diff to GPT for review. Cached answers are
+// keyed by the original content and diff only, leaving out the commit messages, so that a new
+// commit does not invalidate the reviews of the files it leaves unchanged.
+func (c *gpt) Review(ctx context.Context, originalContent, formattedDiff string, opts ReviewOptions) (string, error) {
+	key := []string{opts.PreviousReview, originalContent, formattedDiff}
+	return c.complete(ctx, reviewPrompt(originalContent, opts), formattedDiff, opts.Stream, key)
+}
+
+// Merge consolidates the reviews of the batches of a large change into a single review
+func (c *gpt) Merge(ctx context.Context, reviews []string, opts ReviewOptions) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("<batch-reviews>\n")
+	for i, review := range reviews {
+		sb.WriteString(fmt.Sprintf("<batch-review index=\"%d\">\n%s\n</batch-review>\n", i+1, review))
+	}
+	sb.WriteString("</batch-reviews>")
+
+	prompt := mergePrompt(opts)
+	return c.complete(ctx, prompt, sb.String(), opts.Stream, []string{prompt, sb.String()})
+}
+
+// Repair asks GPT to rewrite a review that could not be parsed in the expected format
+func (c *gpt) Repair(ctx context.Context, review, problem string) (string, error) {
+	prompt := repairPrompt(problem)
+	return c.complete(ctx, prompt, review, nil, []string{prompt, review})
+}
+
+// SetRateLimit limits the requests and tokens sent per minute by this client, including
+// concurrent requests. A limit of zero disables it.
+func (c *gpt) SetRateLimit(requestsPerMinute, tokensPerMinute int) {
+	c.limiter = ratelimit.New(requestsPerMinute, tokensPerMinute)
+}
+
+// SetCache reuses the answers of previous identical requests stored in the cache, and stores
+// new answers in it. A nil cache disables caching.
+func (c *gpt) SetCache(answers cache.ICache) {
+	c.cache = answers
+}
+
+// SetGeneration sets the parameters used to generate answers
+func (c *gpt) SetGeneration(opts GenerationOptions) {
+	c.generation = opts
+}
+
+// ReviewPromptTokens estimates the tokens of the review prompt without any original content or diff
+func ReviewPromptTokens(opts ReviewOptions) int {
+	return EstimateTokens(reviewPrompt("", opts))
+}
+
+// MergePromptTokens estimates the tokens of the merge prompt without any reviews
+func MergePromptTokens(opts ReviewOptions) int {
+	return EstimateTokens(mergePrompt(opts))
+}
+
+// complete sends a system prompt and a user message to GPT and returns the answer. The answer
+// is also written to the stream as it arrives when one is given. The answer is cached under the
+// given content, which identifies the request along with its model and parameters.
+func (c *gpt) complete(ctx context.Context, prompt, message string, stream io.Writer, content []string) (string, error) {
+	request := provider.ChatRequest{
+		Model: c.client.GetModel(),
+		Messages: []provider.Message{
+			{
+				Role:    provider.RoleSystem,
+				Content: prompt,
+			},
+			{
+				Role:    provider.RoleUser,
+				Content: message,
+			},
+		},
+		MaxTokens:       c.generation.MaxTokens,
+		Temperature:     c.generation.Temperature,
+		TopP:            c.generation.TopP,
+		Seed:            c.generation.Seed,
+		ReasoningEffort: c.generation.ReasoningEffort,
+	}
+	if request.MaxTokens <= 0 {
+		request.MaxTokens = DefaultMaxTokens
+	}
+
+	key := c.cacheKey(request, content)
+	if key != "" {
+		answer, found, err := c.cache.Get(key)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		} else if found {
+			log.Printf("Using cached answer for %v characters (%v %v)\n", len(message), c.client.Name(), c.client.GetModel())
+			if stream != nil {
+				io.WriteString(stream, answer)
+			}
+			return answer, nil
+		}
+	}
+
+	log.Printf("Sending %v characters to GPT (%v %v)\n", len(message), c.client.Name(), c.client.GetModel())
+	answer, err := c.chat(ctx, request, stream)
+	if err != nil {
+		return "", err
+	}
+
+	if key != "" {
+		if err := c.cache.Put(key, answer); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return answer, nil
+}
+
+// chat sends a request and returns the answer. Answers cut off at the maximum number of tokens
+// are continued in further requests, up to maxContinuations times, and joined.
+func (c *gpt) chat(ctx context.Context, request provider.ChatRequest, stream io.Writer) (string, error) {
+	messages := request.Messages
+	var answer strings.Builder
+	for continuation := 0; ; continuation++ {
+		// Quotas count the tokens of the prompt and of the longest possible answer
+		tokens := request.MaxTokens
+		for _, m := range request.Messages {
+			tokens += EstimateTokens(m.Content)
+		}
+		if err := c.limiter.Wait(ctx, tokens); err != nil {
+			return "", fmt.Errorf("rate limit error: %v", err)
+		}
+
+		var resp provider.ChatResponse
+		var err error
+		if stream != nil {
+			resp, err = c.client.ChatStream(ctx, request, func(delta string) {
+				io.WriteString(stream, delta)
+			})
+		} else {
+			resp, err = c.client.Chat(ctx, request)
+		}
+		if err != nil {
+			return "", fmt.Errorf("ChatCompletion error: %v", err)
+		}
+		answer.WriteString(resp.Content)
+
+		if resp.FinishReason != provider.FinishLength {
+			return answer.String(), nil
+		}
+		// Reasoning models can spend the whole limit thinking without answering anything
+		if resp.Content == "" {
+			if answer.Len() == 0 {
+				return "", fmt.Errorf("the limit of %d tokens was reached before any answer was generated, please raise -max-tokens", request.MaxTokens)
+			}
+			log.Printf("Warning: the answer is incomplete, please raise -max-tokens")
+			return answer.String(), nil
+		}
+		if continuation == maxContinuations {
+			log.Printf("Warning: the answer is still incomplete after %d continuations, please raise -max-tokens", maxContinuations)
+			return answer.String(), nil
+		}
+
+		log.Printf("The answer was cut off at %d tokens, continuing it (%d/%d)\n", request.MaxTokens, continuation+1, maxContinuations)
+		request.Messages = append(messages[:len(messages):len(messages)],
+			provider.Message{Role: provider.RoleAssistant, Content: answer.String()},
+			provider.Message{Role: provider.RoleUser, Content: continuePrompt},
+		)
+	}
+}
+
+// cacheKey identifies a request by its provider, model, parameters and prompt version, and by
+// the given content in place of its messages. It returns an empty key when caching is disabled.
+func (c *gpt) cacheKey(request provider.ChatRequest, content []string) string {
+	if c.cache == nil {
+		return ""
+	}
+	request.Messages = nil
+	data, err := json.Marshal(request)
+	if err != nil {
+		return ""
+	}
+	return cache.Key(append([]string{PromptVersion, c.client.Name(), string(data)}, content...)...)
+}
+
+// reviewPrompt builds the system prompt of a review
+func reviewPrompt(originalContent string, opts ReviewOptions) string {
 	prompt := fmt.Sprintf(`You are an AI assistant tasked with reviewing code changes based on the original content and a git diff output. Your goal is to ensure the code follows the existing style and conventions of the codebase, while also suggesting improvements to align with best practices. Follow these instructions to complete the review:
 
 1. You will be provided with two pieces of information:
    a. The original content of the files before changes: <original-content>%v</original-content>
    b. The git diff output in XML format: <git-diff>{{CODE_DIFF}}</git-diff>
+   The <changes> of each file are its hunks, each starting with its @@ header. Every line of a hunk starts with its line number in the new version of the file, blank for deleted lines, followed by a marker: + for added lines, - for deleted lines and a space for unchanged lines.
+   Each file in the diff has a <change-type> (modified, added, deleted, renamed or copied) and, for renames and copies, the <old-name> it came from. Deleted files, renames and copies without content changes, and binary files have a <summary> instead of <changes> and no original content.
+   The original content of a file may be missing when it is not available, in which case review its changes on their own, without assuming what the rest of the file contains.
+   The original content of a file may be limited to <excerpt> elements around the changes, each with the range of original line numbers it covers, instead of the whole file.
 
 2. Analyze both the original content and the changes to:
    a. Understand the context of the changes
@@ -50,7 +251,21 @@ func (c *gpt) Review(originalContent, formattedDiff string) (string, error) {
    c. Provide explanations for why these changes would be beneficial
 
 6. Provide your review in the following format:
-   <review>
+`+reviewFormat+`
+
+Remember to be constructive in your feedback and provide clear explanations for your suggestions. Focus on maintaining consistency with the existing codebase while promoting best practices for the specified programming language(s).`, originalContent)
+
+	if len(opts.CommitMessages) > 0 {
+		prompt += "\n\n" + commitMessagesPrompt(opts.CommitMessages)
+	}
+	if opts.PreviousReview != "" {
+		prompt += "\n\n" + previousReviewPrompt(opts.PreviousReview)
+	}
+	return prompt
+}
+
+// reviewFormat describes the format of a review, which is parsed by the review package
+const reviewFormat = `   <review>
    <style_and_conventions>
    [List observations about code style and conventions, including any inconsistencies or areas for improvement]
    </style_and_conventions>
@@ -67,40 +282,65 @@ func (c *gpt) Review(originalContent, formattedDiff string) (string, error) {
    [Provide a brief summary of the overall code changes and your main recommendations]
    </summary>
 
-   <suggest_changes>
-   [List files and lines where changes are suggested, along with the recommended modifications, make sure file is not duplicated for each recommendation] as per the following example:
-   <file>
-     <n>file_name</n>
-     <line>line_number</line>
-     <change>proposed_change</change>
-   </file>
-   </suggest_changes>
-   </review>
+   <findings>
+   [List every issue and suggested change as a separate finding, as per the following example. The lines are the line numbers shown in the diff, which are those of the new version of the file; only refer to lines shown in the diff, and use the same number for the start and end lines of a single line. The severity is critical for bugs and security issues that break the code or put users at risk, major for likely bugs and problems that should be fixed before merging, minor for small departures from the conventions or best practices, and info for remarks and optional suggestions. The category is one of style, comments, best-practice, bug, security or performance. Wrap the message and the suggestion in CDATA so that code does not need to be escaped, and leave the suggestion empty when there is no concrete change to propose.]
+   <finding>
+     <file>path/to/file</file>
+     <start_line>start_line_number</start_line>
+     <end_line>end_line_number</end_line>
+     <severity>info|minor|major|critical</severity>
+     <category>category</category>
+     <message><![CDATA[what is wrong and why it matters]]></message>
+     <suggestion><![CDATA[proposed_change]]></suggestion>
+   </finding>
+   </findings>
+   </review>`
 
-Remember to be constructive in your feedback and provide clear explanations for your suggestions. Focus on maintaining consistency with the existing codebase while promoting best practices for the specified programming language(s).`, originalContent)
+// repairPrompt asks to rewrite a review that does not follow the expected format
+func repairPrompt(problem string) string {
+	return fmt.Sprintf(`You are an AI assistant fixing the format of a code review. The review you will be given could not be parsed: %s.
 
-	log.Printf("Sending %v characters to GPT (%v)\n", len(formattedDiff), c.client.GetModel())
-	resp, err := c.client.CreateChatCompletion(
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: c.client.GetModel(),
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: prompt,
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: formattedDiff,
-				},
-			},
-			MaxTokens: 1000,
-		},
-	)
+Rewrite it in the following format, keeping every observation and finding of the original review without adding new ones. Answer with the review only.
+%s`, problem, reviewFormat)
+}
 
-	if err != nil {
-		return "", fmt.Errorf("ChatCompletion error: %v", err)
+// mergePrompt builds the system prompt consolidating the reviews of several batches
+func mergePrompt(opts ReviewOptions) string {
+	prompt := `You are an AI assistant consolidating code reviews. The changes under review were too large to review at once, so the files were split into batches and each batch was reviewed separately. You will be given the review of every batch in <batch-review> elements.
+
+Merge them into a single review of the whole change:
+1. Keep every distinct observation and suggestion, and remove duplicates
+2. Resolve contradictions between batches, preferring the more specific observation
+3. Write a summary covering the whole change rather than individual batches
+4. Keep every finding with its file, lines, severity and category, merging findings about the same lines
+
+Provide the merged review in the same <review> format as the batch reviews, with the <style_and_conventions>, <comments_review>, <best_practices>, <summary> and <findings> sections.`
+
+	if len(opts.CommitMessages) > 0 {
+		prompt += "\n\n" + commitMessagesPrompt(opts.CommitMessages)
+	}
+	if opts.PreviousReview != "" {
+		prompt += "\n\nThe batch reviews also assess the findings of a previous review in a <previous_findings> section. Include a single <previous_findings> section in the merged review listing every previous finding once: it still applies if any batch says so, and it is addressed only if a batch says so and none says it still applies."
 	}
+	return prompt
+}
+
+// commitMessagesPrompt describes the intent of the changes using the commit messages that introduced them
+func commitMessagesPrompt(messages []string) string {
+	var sb strings.Builder
+	sb.WriteString("The author described the intent of these changes in the following commit message(s). Use them to understand what the changes are meant to do, and point out any change that does not match the described intent:\n")
+	sb.WriteString("<commit-messages>\n")
+	for _, message := range messages {
+		sb.WriteString(fmt.Sprintf("<commit-message><![CDATA[%s]]></commit-message>\n", message))
+	}
+	sb.WriteString("</commit-messages>")
+	return sb.String()
+}
 
-	return resp.Choices[0].Message.Content, nil
+// previousReviewPrompt asks which findings of the review of earlier commits still apply
+func previousReviewPrompt(review string) string {
+	var sb strings.Builder
+	sb.WriteString("The branch was reviewed before, and only the changes added since that review are shown. The previous review is included below. Use the original content to judge whether each of its findings has been addressed by the new changes, and add a <previous_findings> section to your review listing every previous finding as 'still applies', 'addressed' or 'not affected by these changes', with a short explanation:\n")
+	sb.WriteString(fmt.Sprintf("<previous-review><![CDATA[%s]]></previous-review>", review))
+	return sb.String()
 }
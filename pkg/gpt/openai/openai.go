@@ -2,29 +2,54 @@ package openai
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
 
 	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/lmquang/code-review/pkg/gpt/provider"
 )
 
-// Client represents a GPT client
+// Client represents a GPT client for the OpenAI API or an Azure OpenAI deployment
 type openAI struct {
 	client *openai.Client
 	model  string
+	name   string
 }
 
-type IOpenAI interface {
-	SetModel(model string)
-	GetModel() string
-	CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
-}
-
-func NewOpenAI(client *openai.Client, model string) IOpenAI {
+// NewOpenAI creates a provider for the OpenAI API
+func NewOpenAI(client *openai.Client, model string) provider.IProvider {
 	return &openAI{
 		client: client,
 		model:  model,
+		name:   provider.OpenAI,
+	}
+}
+
+// NewAzure creates a provider for Azure OpenAI. The model is the name of the deployment
+// on the resource at the given endpoint, and the API version defaults to the SDK's when empty.
+func NewAzure(config openai.ClientConfig, deployment, apiVersion string) provider.IProvider {
+	if apiVersion != "" {
+		config.APIVersion = apiVersion
+	}
+	// Requests name the deployment directly rather than a model mapped to it
+	config.AzureModelMapperFunc = func(model string) string {
+		return model
+	}
+	return &openAI{
+		client: openai.NewClientWithConfig(config),
+		model:  deployment,
+		name:   provider.Azure,
 	}
 }
 
+func (c *openAI) Name() string {
+	return c.name
+}
+
 func (c *openAI) SetModel(model string) {
 	c.model = model
 }
@@ -33,6 +58,107 @@ func (c *openAI) GetModel() string {
 	return c.model
 }
 
-func (c *openAI) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
-	return c.client.CreateChatCompletion(ctx, request)
+func (c *openAI) Chat(ctx context.Context, request provider.ChatRequest) (provider.ChatResponse, error) {
+	resp, err := c.client.CreateChatCompletion(ctx, completionRequest(request))
+	if err != nil {
+		return provider.ChatResponse{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return provider.ChatResponse{}, fmt.Errorf("response has no choices")
+	}
+
+	choice := resp.Choices[0]
+	return provider.ChatResponse{
+		Content:      choice.Message.Content,
+		FinishReason: string(choice.FinishReason),
+	}, nil
+}
+
+// ChatStream streams the answer, calling onDelta with every piece of it as it arrives, and
+// returns the complete answer
+func (c *openAI) ChatStream(ctx context.Context, request provider.ChatRequest, onDelta func(delta string)) (provider.ChatResponse, error) {
+	stream, err := c.client.CreateChatCompletionStream(ctx, completionRequest(request))
+	if err != nil {
+		return provider.ChatResponse{}, err
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var finishReason string
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return provider.ChatResponse{}, err
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+
+		choice := resp.Choices[0]
+		if delta := choice.Delta.Content; delta != "" {
+			content.WriteString(delta)
+			onDelta(delta)
+		}
+		if choice.FinishReason != "" {
+			finishReason = string(choice.FinishReason)
+		}
+	}
+
+	return provider.ChatResponse{
+		Content:      content.String(),
+		FinishReason: finishReason,
+	}, nil
+}
+
+// completionRequest converts a provider-neutral request to a chat completion request
+func completionRequest(request provider.ChatRequest) openai.ChatCompletionRequest {
+	messages := make([]openai.ChatCompletionMessage, 0, len(request.Messages))
+	for _, message := range request.Messages {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    string(message.Role),
+			Content: message.Content,
+		})
+	}
+
+	completion := openai.ChatCompletionRequest{
+		Model:           request.Model,
+		Messages:        messages,
+		Seed:            request.Seed,
+		ReasoningEffort: request.ReasoningEffort,
+	}
+	// Reasoning models reject max_tokens, while older servers only know it
+	if request.ReasoningEffort != "" || reasoningModel(request.Model) {
+		completion.MaxCompletionTokens = request.MaxTokens
+	} else {
+		completion.MaxTokens = request.MaxTokens
+	}
+	if request.Temperature != nil {
+		completion.Temperature = nonZero(*request.Temperature)
+	}
+	if request.TopP != nil {
+		completion.TopP = nonZero(*request.TopP)
+	}
+	return completion
+}
+
+// reasoningModel reports whether a model is one of the o-series reasoning models
+func reasoningModel(model string) bool {
+	for _, prefix := range []string{"o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// nonZero converts a sampling parameter for the SDK, which omits zero values from requests.
+// Zero is sent as the smallest positive value instead, which behaves the same.
+func nonZero(value float64) float32 {
+	if value == 0 {
+		return math.SmallestNonzeroFloat32
+	}
+	return float32(value)
 }
@@ -0,0 +1,123 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lmquang/code-review/pkg/gpt/provider"
+)
+
+func chatServer(t *testing.T, path string, choices string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, path, r.URL.Path)
+
+		var body openai.ChatCompletionRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, 1000, body.MaxTokens)
+		assert.Equal(t, []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "Review the diff"},
+			{Role: openai.ChatMessageRoleUser, Content: "diff"},
+		}, body.Messages)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":` + choices + `}`))
+	}))
+}
+
+var request = provider.ChatRequest{
+	Model: "model",
+	Messages: []provider.Message{
+		{Role: provider.RoleSystem, Content: "Review the diff"},
+		{Role: provider.RoleUser, Content: "diff"},
+	},
+	MaxTokens: 1000,
+}
+
+func TestOpenAI_Chat(t *testing.T) {
+	server := chatServer(t, "/v1/chat/completions", `[{"message":{"role":"assistant","content":"<review></review>"},"finish_reason":"length"}]`)
+	defer server.Close()
+
+	config := openai.DefaultConfig("test-key")
+	config.BaseURL = server.URL + "/v1"
+	client := NewOpenAI(openai.NewClientWithConfig(config), "model")
+
+	resp, err := client.Chat(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, provider.ChatResponse{Content: "<review></review>", FinishReason: provider.FinishLength}, resp)
+	assert.Equal(t, provider.OpenAI, client.Name())
+}
+
+func TestOpenAI_ChatWithoutChoices(t *testing.T) {
+	server := chatServer(t, "/v1/chat/completions", `[]`)
+	defer server.Close()
+
+	config := openai.DefaultConfig("test-key")
+	config.BaseURL = server.URL + "/v1"
+	client := NewOpenAI(openai.NewClientWithConfig(config), "model")
+
+	_, err := client.Chat(context.Background(), request)
+	assert.EqualError(t, err, "response has no choices")
+}
+
+func TestAzure_Chat(t *testing.T) {
+	server := chatServer(t, "/openai/deployments/model/chat/completions", `[{"message":{"role":"assistant","content":"<review></review>"},"finish_reason":"stop"}]`)
+	defer server.Close()
+
+	client := NewAzure(openai.DefaultAzureConfig("test-key", server.URL), "model", "2024-06-01")
+
+	resp, err := client.Chat(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, provider.ChatResponse{Content: "<review></review>", FinishReason: provider.FinishStop}, resp)
+	assert.Equal(t, provider.Azure, client.Name())
+}
+
+func TestOpenAI_ChatStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body openai.ChatCompletionRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.True(t, body.Stream)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"choices":[{"delta":{"role":"assistant","content":"<review>"}}]}` + "\n\n" +
+			`data: {"choices":[{"delta":{"content":"</review>"}}]}` + "\n\n" +
+			`data: {"choices":[{"delta":{},"finish_reason":"stop"}]}` + "\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	config := openai.DefaultConfig("test-key")
+	config.BaseURL = server.URL + "/v1"
+	client := NewOpenAI(openai.NewClientWithConfig(config), "model")
+
+	var deltas []string
+	resp, err := client.ChatStream(context.Background(), request, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"<review>", "</review>"}, deltas)
+	assert.Equal(t, provider.ChatResponse{Content: "<review></review>", FinishReason: provider.FinishStop}, resp)
+}
+
+func TestCompletionRequest(t *testing.T) {
+	temperature, topP, seed := 0.0, 0.5, 7
+
+	completion := completionRequest(provider.ChatRequest{Model: "gpt-4o-mini", MaxTokens: 1000, Temperature: &temperature, TopP: &topP, Seed: &seed})
+	assert.Equal(t, 1000, completion.MaxTokens)
+	assert.Equal(t, 0, completion.MaxCompletionTokens)
+	assert.Equal(t, float32(math.SmallestNonzeroFloat32), completion.Temperature)
+	assert.Equal(t, float32(0.5), completion.TopP)
+	assert.Equal(t, &seed, completion.Seed)
+
+	completion = completionRequest(provider.ChatRequest{Model: "o3-mini", MaxTokens: 1000, ReasoningEffort: "low"})
+	assert.Equal(t, 0, completion.MaxTokens)
+	assert.Equal(t, 1000, completion.MaxCompletionTokens)
+	assert.Equal(t, "low", completion.ReasoningEffort)
+	assert.Zero(t, completion.Temperature)
+}
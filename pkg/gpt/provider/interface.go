@@ -0,0 +1,11 @@
+package provider
+
+import "context"
+
+type IProvider interface {
+	Name() string
+	SetModel(model string)
+	GetModel() string
+	Chat(ctx context.Context, request ChatRequest) (ChatResponse, error)
+	ChatStream(ctx context.Context, request ChatRequest, onDelta func(delta string)) (ChatResponse, error)
+}
@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxLineSize is the longest line of a streamed response
+const maxLineSize = 1024 * 1024
+
+// Names of the supported providers
+const (
+	OpenAI    = "openai"
+	Azure     = "azure"
+	Anthropic = "anthropic"
+	Ollama    = "ollama"
+)
+
+// Role is the author of a chat message
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Reasons a provider stopped generating an answer
+const (
+	// FinishStop means the answer is complete
+	FinishStop = "stop"
+	// FinishLength means the answer was cut off at the maximum number of tokens
+	FinishLength = "length"
+)
+
+// Message is a single message of a conversation
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is a chat completion request independent of the provider
+type ChatRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	// MaxTokens is the maximum number of tokens of the answer
+	MaxTokens int `json:"max_tokens"`
+	// Temperature, TopP and Seed are left to the provider's defaults when nil
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	// ReasoningEffort is how long reasoning models think before answering, such as "low",
+	// "medium" or "high", and is ignored by providers and models that do not reason
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+}
+
+// ChatResponse is the answer to a chat completion request
+type ChatResponse struct {
+	Content string
+	// FinishReason is why the provider stopped, such as FinishStop or FinishLength
+	FinishReason string
+}
+
+// HTTPError is an unsuccessful response of a provider's HTTP API
+type HTTPError struct {
+	StatusCode int
+	// Message is the error message of the response, or its body when it has none
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+
+// PostJSON sends a JSON request and decodes the JSON response into out. Unsuccessful
+// responses are returned as an *HTTPError whose message is extracted by errorMessage.
+func PostJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}, errorMessage func([]byte) string) error {
+	respBody, err := PostStream(ctx, client, url, headers, body, errorMessage)
+	if err != nil {
+		return err
+	}
+	defer respBody.Close()
+
+	data, err := io.ReadAll(respBody)
+	if err != nil {
+		return fmt.Errorf("error reading response: %v", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	return nil
+}
+
+// PostStream sends a JSON request and returns the body of a successful response, such as a
+// stream of events, which the caller must close. Unsuccessful responses are returned as an
+// *HTTPError whose message is extracted by errorMessage.
+func PostStream(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}, errorMessage func([]byte) string) (io.ReadCloser, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp.Body, nil
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+	message := errorMessage(respBody)
+	if message == "" {
+		message = string(respBody)
+	}
+	return nil, &HTTPError{StatusCode: resp.StatusCode, Message: message}
+}
+
+// ScanLines calls fn with every line of a streamed response, stopping at the first error.
+// Lines may be as long as a single event of the stream.
+func ScanLines(r io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading stream: %v", err)
+	}
+	return nil
+}
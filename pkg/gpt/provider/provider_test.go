@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Key"))
+
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		switch body["case"] {
+		case "ok":
+			w.Write([]byte(`{"answer":"42"}`))
+		case "error":
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"slow down"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("boom"))
+		}
+	}))
+	defer server.Close()
+
+	errorMessage := func(body []byte) string {
+		var resp struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(body, &resp)
+		return resp.Error
+	}
+	post := func(c string) (map[string]string, error) {
+		var out map[string]string
+		err := PostJSON(context.Background(), server.Client(), server.URL, map[string]string{"X-Key": "secret"}, map[string]string{"case": c}, &out, errorMessage)
+		return out, err
+	}
+
+	out, err := post("ok")
+	assert.NoError(t, err)
+	assert.Equal(t, "42", out["answer"])
+
+	_, err = post("error")
+	var httpErr *HTTPError
+	assert.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusTooManyRequests, httpErr.StatusCode)
+	assert.Equal(t, "status 429: slow down", err.Error())
+
+	_, err = post("other")
+	assert.EqualError(t, err, "status 500: boom")
+}
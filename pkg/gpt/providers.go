@@ -0,0 +1,98 @@
+package gpt
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/lmquang/code-review/pkg/gpt/anthropic"
+	"github.com/lmquang/code-review/pkg/gpt/ollama"
+	gptopenai "github.com/lmquang/code-review/pkg/gpt/openai"
+	"github.com/lmquang/code-review/pkg/gpt/provider"
+	"github.com/lmquang/code-review/pkg/retry"
+)
+
+// ProviderOptions selects and configures the chat provider that reviews the changes
+type ProviderOptions struct {
+	// Provider is the name of the provider, OpenAI when empty
+	Provider string
+	APIKey   string
+	// Model is the model, or the deployment for Azure OpenAI. The provider's default model
+	// is used when empty.
+	Model string
+	// BaseURL is the endpoint of the API. It is required for Azure OpenAI, and defaults to the
+	// public API for OpenAI and Anthropic and to a local server for Ollama. For OpenAI, it can
+	// point to any server implementing the chat completions API, such as vLLM or a gateway.
+	BaseURL string
+	// APIVersion is the API version of Azure OpenAI
+	APIVersion string
+	// Organization is the OpenAI organization the requests are billed to
+	Organization string
+	// Headers are added to every request, replacing headers of the same name set by the provider
+	Headers map[string]string
+	// MaxRetries is the number of times a request failing with a transient error is retried
+	MaxRetries int
+	// Timeout limits every attempt of a request, including reading the answer. Zero disables it.
+	Timeout time.Duration
+}
+
+// NewProvider creates the chat provider described by the options
+func NewProvider(opts ProviderOptions) (provider.IProvider, error) {
+	transport := http.DefaultTransport
+	if len(opts.Headers) > 0 {
+		transport = &headerTransport{headers: opts.Headers, base: transport}
+	}
+	httpClient := &http.Client{Transport: retry.NewTransport(transport, opts.MaxRetries, opts.Timeout)}
+
+	switch opts.Provider {
+	case "", provider.OpenAI:
+		config := openai.DefaultConfig(opts.APIKey)
+		if opts.BaseURL != "" {
+			config.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+		}
+		config.OrgID = opts.Organization
+		config.HTTPClient = httpClient
+		return gptopenai.NewOpenAI(openai.NewClientWithConfig(config), valueOrDefault(opts.Model, openai.GPT4oMini)), nil
+	case provider.Azure:
+		if opts.BaseURL == "" {
+			return nil, fmt.Errorf("the azure provider requires the endpoint of the resource")
+		}
+		if opts.Model == "" {
+			return nil, fmt.Errorf("the azure provider requires the name of a deployment")
+		}
+		config := openai.DefaultAzureConfig(opts.APIKey, opts.BaseURL)
+		config.HTTPClient = httpClient
+		return gptopenai.NewAzure(config, opts.Model, opts.APIVersion), nil
+	case provider.Anthropic:
+		return anthropic.NewAnthropic(httpClient, valueOrDefault(opts.BaseURL, anthropic.DefaultBaseURL), opts.APIKey, valueOrDefault(opts.Model, anthropic.DefaultModel)), nil
+	case provider.Ollama:
+		return ollama.NewOllama(httpClient, valueOrDefault(opts.BaseURL, ollama.DefaultBaseURL), valueOrDefault(opts.Model, ollama.DefaultModel)), nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q, expected %q, %q, %q or %q", opts.Provider, provider.OpenAI, provider.Azure, provider.Anthropic, provider.Ollama)
+	}
+}
+
+func valueOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// headerTransport adds fixed headers to every request
+type headerTransport struct {
+	headers map[string]string
+	base    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request it is given
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,16 @@
+package gpt
+
+// DefaultTokenBudget is the default number of prompt tokens sent in a single review request
+const DefaultTokenBudget = 60000
+
+// DefaultMaxTokens is the default maximum number of tokens of a single answer
+const DefaultMaxTokens = 1000
+
+// bytesPerToken is the average number of bytes per token of code and English text
+const bytesPerToken = 4
+
+// EstimateTokens estimates the number of tokens of a text. It counts bytes rather than
+// characters so that non-ASCII text is overestimated rather than underestimated.
+func EstimateTokens(s string) int {
+	return (len(s) + bytesPerToken - 1) / bytesPerToken
+}
@@ -0,0 +1,81 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry is the last review of a branch
+type Entry struct {
+	// Head is the commit the branch pointed to when it was reviewed
+	Head string `json:"head"`
+	// Review is the answer of the review
+	Review     string    `json:"review"`
+	ReviewedAt time.Time `json:"reviewed_at"`
+}
+
+// FileHistory stores the last review of every branch in a JSON file
+type FileHistory struct {
+	path string
+}
+
+// NewFileHistory creates a history stored in the given file
+func NewFileHistory(path string) IHistory {
+	return &FileHistory{path: path}
+}
+
+// Path returns the file of the history of a repository inside its .git directory
+func Path(gitDir string) string {
+	return filepath.Join(gitDir, "code-review", "reviews.json")
+}
+
+// Last returns the last review of a branch and whether there is one
+func (h *FileHistory) Last(branch string) (Entry, bool, error) {
+	entries, err := h.load()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	entry, found := entries[branch]
+	return entry, found, nil
+}
+
+// Save replaces the last review of a branch
+func (h *FileHistory) Save(branch string, entry Entry) error {
+	entries, err := h.load()
+	if err != nil {
+		return err
+	}
+	entries[branch] = entry
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding review history: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return fmt.Errorf("error creating review history directory: %v", err)
+	}
+	if err := os.WriteFile(h.path, data, 0600); err != nil {
+		return fmt.Errorf("error writing review history: %v", err)
+	}
+	return nil
+}
+
+// load reads the entries of every branch, returning an empty map when there is no history yet
+func (h *FileHistory) load() (map[string]Entry, error) {
+	entries := make(map[string]Entry)
+	data, err := os.ReadFile(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading review history: %v", err)
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error decoding review history: %v", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,48 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileHistory(t *testing.T) {
+	gitDir := t.TempDir()
+	h := NewFileHistory(Path(gitDir))
+
+	_, found, err := h.Last("feature")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	first := Entry{Head: "abc123", Review: "<review>first</review>", ReviewedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	assert.NoError(t, h.Save("feature", first))
+	assert.NoError(t, h.Save("other", Entry{Head: "def456"}))
+
+	entry, found, err := h.Last("feature")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, first, entry)
+
+	second := Entry{Head: "fed789", Review: "<review>second</review>"}
+	assert.NoError(t, h.Save("feature", second))
+	entry, _, _ = h.Last("feature")
+	assert.Equal(t, second, entry)
+
+	entry, found, _ = h.Last("other")
+	assert.True(t, found)
+	assert.Equal(t, "def456", entry.Head)
+	assert.FileExists(t, filepath.Join(gitDir, "code-review", "reviews.json"))
+}
+
+func TestFileHistory_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reviews.json")
+	assert.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+
+	h := NewFileHistory(path)
+	_, _, err := h.Last("feature")
+	assert.Error(t, err)
+	assert.Error(t, h.Save("feature", Entry{}))
+}
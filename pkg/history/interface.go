@@ -0,0 +1,6 @@
+package history
+
+type IHistory interface {
+	Last(branch string) (Entry, bool, error)
+	Save(branch string, entry Entry) error
+}
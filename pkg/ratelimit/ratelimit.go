@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter limits the number of requests and tokens sent per minute. It is safe for
+// concurrent use, so a single limiter can be shared by every request to the same quota.
+type Limiter struct {
+	requestsPerMinute int
+	tokensPerMinute   int
+	window            time.Duration
+
+	mu      sync.Mutex
+	history []usage
+}
+
+// usage is a request recorded in the current window
+type usage struct {
+	at     time.Time
+	tokens int
+}
+
+// New creates a limiter allowing the given number of requests and tokens per minute.
+// A limit of zero or less disables that limit.
+func New(requestsPerMinute, tokensPerMinute int) *Limiter {
+	return &Limiter{
+		requestsPerMinute: requestsPerMinute,
+		tokensPerMinute:   tokensPerMinute,
+		window:            time.Minute,
+	}
+}
+
+// Wait blocks until a request of the given number of tokens fits within the limits, then
+// records it. A request larger than the tokens per minute is let through once the window is
+// empty, since it would never fit otherwise.
+func (l *Limiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+
+	for {
+		delay := l.reserve(tokens)
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve records the request and returns zero when it fits, or the time to wait before trying again
+func (l *Limiter) reserve(tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for len(l.history) > 0 && now.Sub(l.history[0].at) >= l.window {
+		l.history = l.history[1:]
+	}
+
+	used := 0
+	for _, u := range l.history {
+		used += u.tokens
+	}
+
+	requestsOK := l.requestsPerMinute <= 0 || len(l.history) < l.requestsPerMinute
+	tokensOK := l.tokensPerMinute <= 0 || used+tokens <= l.tokensPerMinute || len(l.history) == 0
+	if requestsOK && tokensOK {
+		l.history = append(l.history, usage{at: now, tokens: tokens})
+		return 0
+	}
+
+	// Capacity is freed when the oldest request leaves the window
+	return l.history[0].at.Add(l.window).Sub(now)
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLimiter(requestsPerMinute, tokensPerMinute int) *Limiter {
+	l := New(requestsPerMinute, tokensPerMinute)
+	l.window = 50 * time.Millisecond
+	return l
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	t.Run("Unlimited", func(t *testing.T) {
+		l := New(0, 0)
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, l.Wait(context.Background(), 1000))
+		}
+	})
+
+	t.Run("Nil limiter", func(t *testing.T) {
+		var l *Limiter
+		assert.NoError(t, l.Wait(context.Background(), 1000))
+	})
+
+	t.Run("Requests per minute", func(t *testing.T) {
+		l := newTestLimiter(2, 0)
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, l.Wait(context.Background(), 1))
+		}
+		assert.GreaterOrEqual(t, time.Since(start), l.window)
+	})
+
+	t.Run("Tokens per minute", func(t *testing.T) {
+		l := newTestLimiter(0, 100)
+		start := time.Now()
+		assert.NoError(t, l.Wait(context.Background(), 60))
+		assert.Less(t, time.Since(start), l.window)
+		assert.NoError(t, l.Wait(context.Background(), 60))
+		assert.GreaterOrEqual(t, time.Since(start), l.window)
+	})
+
+	t.Run("Oversized request on an empty window", func(t *testing.T) {
+		l := newTestLimiter(0, 100)
+		start := time.Now()
+		assert.NoError(t, l.Wait(context.Background(), 500))
+		assert.Less(t, time.Since(start), l.window)
+	})
+
+	t.Run("Canceled context", func(t *testing.T) {
+		l := New(1, 0)
+		assert.NoError(t, l.Wait(context.Background(), 1))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, l.Wait(ctx, 1), context.DeadlineExceeded)
+	})
+
+	t.Run("Concurrent requests", func(t *testing.T) {
+		l := newTestLimiter(5, 0)
+		var wg sync.WaitGroup
+		start := time.Now()
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, l.Wait(context.Background(), 1))
+			}()
+		}
+		wg.Wait()
+		assert.GreaterOrEqual(t, time.Since(start), l.window)
+	})
+}
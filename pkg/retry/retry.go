@@ -0,0 +1,187 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is the number of times a failed request is retried by default
+	DefaultMaxRetries = 3
+	// DefaultTimeout is the default time allowed for a single attempt, including reading the response
+	DefaultTimeout = 5 * time.Minute
+
+	// baseDelay is the delay before the first retry, doubled for every further retry
+	baseDelay = time.Second
+	// maxDelay caps the exponential backoff, but not delays requested with Retry-After
+	maxDelay = 30 * time.Second
+)
+
+// Transport retries requests that fail with a transient error, such as a rate limit, a
+// server error or a network failure, with exponential backoff and jitter. Delays requested
+// by the server with Retry-After are honored. Each attempt is limited by a timeout.
+type Transport struct {
+	base       http.RoundTripper
+	maxRetries int
+	timeout    time.Duration
+	// sleep waits between attempts, returning early with an error when the context is done
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewTransport wraps a transport with retries. A timeout of zero lets attempts run until the
+// request's context is done.
+func NewTransport(base http.RoundTripper, maxRetries int, timeout time.Duration) *Transport {
+	return &Transport{
+		base:       base,
+		maxRetries: maxRetries,
+		timeout:    timeout,
+		sleep:      sleep,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.attempt(req, attempt)
+		if attempt >= t.maxRetries || !retryable(req, resp, err) {
+			return resp, err
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header); ok {
+				delay = after
+			}
+			log.Printf("Request failed with status %d, retrying in %v (retry %d/%d)\n", resp.StatusCode, delay.Round(time.Millisecond), attempt+1, t.maxRetries)
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			log.Printf("Request failed: %v, retrying in %v (retry %d/%d)\n", err, delay.Round(time.Millisecond), attempt+1, t.maxRetries)
+		}
+
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends a copy of the request with a fresh body, limited by the timeout
+func (t *Transport) attempt(req *http.Request, attempt int) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+	}
+
+	// A RoundTripper must not modify the request it is given
+	clone := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		clone.Body = body
+	}
+
+	resp, err := t.base.RoundTrip(clone)
+	if err != nil {
+		cancel()
+		if ctx.Err() == context.DeadlineExceeded && req.Context().Err() == nil {
+			return nil, &timeoutError{timeout: t.timeout}
+		}
+		return nil, err
+	}
+	// The timeout also covers reading the body, so it is released when the body is closed
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retryable reports whether a failed attempt is worth retrying. Requests whose body cannot
+// be sent again are not retried, nor are requests whose context is done.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case 529:
+		// Anthropic reports overload with a non-standard status
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before a retry, doubling with every attempt up to a maximum.
+// The delay is randomized between half and all of it, so that concurrent requests failing
+// together do not retry together.
+func backoff(attempt int) time.Duration {
+	delay := maxDelay
+	if attempt < 16 {
+		delay = min(baseDelay<<attempt, maxDelay)
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter parses the delay requested by the server, in milliseconds with retry-after-ms as
+// sent by OpenAI, or in seconds or as an HTTP date with Retry-After
+func retryAfter(header http.Header) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancelBody releases the context of an attempt when its response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// timeoutError reports an attempt that did not complete within the timeout
+type timeoutError struct {
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return "request timed out after " + e.timeout.String()
+}
@@ -0,0 +1,164 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestTransport creates a transport recording its delays instead of sleeping
+func newTestTransport(maxRetries int, timeout time.Duration, delays *[]time.Duration) *Transport {
+	transport := NewTransport(http.DefaultTransport, maxRetries, timeout)
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return ctx.Err()
+	}
+	return transport
+}
+
+func TestTransport_RoundTrip(t *testing.T) {
+	t.Run("Honors Retry-After", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "payload", string(body))
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				w.Header().Set("Retry-After", "2")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+
+		var delays []time.Duration
+		client := &http.Client{Transport: newTestTransport(3, 0, &delays)}
+		resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(2), attempts)
+		assert.Equal(t, []time.Duration{2 * time.Second}, delays)
+	})
+
+	t.Run("Gives up after the maximum retries", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		var delays []time.Duration
+		client := &http.Client{Transport: newTestTransport(3, 0, &delays)}
+		resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(4), attempts)
+		if assert.Len(t, delays, 3) {
+			for i, delay := range delays {
+				full := baseDelay << i
+				assert.GreaterOrEqual(t, delay, full/2)
+				assert.LessOrEqual(t, delay, full)
+			}
+		}
+	})
+
+	t.Run("Does not retry client errors", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		var delays []time.Duration
+		client := &http.Client{Transport: newTestTransport(3, 0, &delays)}
+		resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, int32(1), attempts)
+		assert.Empty(t, delays)
+	})
+
+	t.Run("Retries attempts that time out", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.ReadAll(r.Body)
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+
+		var delays []time.Duration
+		client := &http.Client{Transport: newTestTransport(1, 100*time.Millisecond, &delays)}
+		resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", string(body))
+		assert.Equal(t, int32(2), attempts)
+	})
+
+	t.Run("Stops when the context is canceled", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		transport := NewTransport(http.DefaultTransport, 3, 0)
+		transport.sleep = func(ctx context.Context, d time.Duration) error {
+			cancel()
+			return ctx.Err()
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader("payload"))
+		assert.NoError(t, err)
+		_, err = (&http.Client{Transport: transport}).Do(req)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+		found    bool
+	}{
+		{name: "Missing", header: http.Header{}},
+		{name: "Seconds", header: http.Header{"Retry-After": {"3"}}, expected: 3 * time.Second, found: true},
+		{name: "Milliseconds", header: http.Header{"Retry-After-Ms": {"250"}, "Retry-After": {"1"}}, expected: 250 * time.Millisecond, found: true},
+		{name: "Past date", header: http.Header{"Retry-After": {"Wed, 21 Oct 2015 07:28:00 GMT"}}, expected: 0, found: true},
+		{name: "Invalid", header: http.Header{"Retry-After": {"soon"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, found := retryAfter(tt.header)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.expected, delay)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package review
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// encodeCheckstyle writes the findings as a checkstyle report, grouped by file. Checkstyle
+// reports a single line, so findings are reported at their first line.
+func encodeCheckstyle(w io.Writer, review Review) error {
+	report := checkstyleReport{Version: "4.3"}
+	files, grouped := byFile(review.Findings)
+	for _, file := range files {
+		entry := checkstyleFile{Name: file}
+		for _, finding := range grouped[file] {
+			category := finding.Category
+			if category == "" {
+				category = defaultCategory
+			}
+			entry.Errors = append(entry.Errors, checkstyleError{
+				Line:     finding.StartLine,
+				Severity: checkstyleSeverity(finding.Severity),
+				Message:  fullMessage(finding),
+				Source:   toolName + "." + category,
+			})
+		}
+		report.Files = append(report.Files, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// checkstyleSeverity maps a severity to a checkstyle severity
+func checkstyleSeverity(severity Severity) string {
+	switch severity {
+	case SeverityCritical, SeverityMajor:
+		return "error"
+	case SeverityMinor:
+		return "warning"
+	default:
+		return "info"
+	}
+}
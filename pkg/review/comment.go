@@ -0,0 +1,46 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Comment renders a finding as a Markdown comment on its lines, for code hosting platforms
+func Comment(finding Finding) string {
+	label := fmt.Sprintf("**%s**", finding.Severity)
+	if finding.Category != "" {
+		label += " (" + finding.Category + ")"
+	}
+	if finding.StartLine != finding.EndLine {
+		label += ", " + lines(finding)
+	}
+
+	comment := label + ": " + finding.Message
+	if finding.Suggestion != "" {
+		fence := codeFence(finding.Suggestion)
+		comment += fmt.Sprintf("\n\nSuggestion:\n\n%s\n%s\n%s", fence, finding.Suggestion, fence)
+	}
+	return comment
+}
+
+// CommentSummary renders the summary of a review as a Markdown comment for code hosting
+// platforms, with the findings of the review grouped by file. Inline is the number of other
+// findings, which are commented on their lines instead.
+func CommentSummary(review Review, inline int) string {
+	var sb strings.Builder
+	sb.WriteString("## Code review\n\n")
+	if review.Summary != "" {
+		sb.WriteString(review.Summary + "\n\n")
+	} else {
+		sb.WriteString("No summary.\n\n")
+	}
+
+	switch {
+	case inline > 0:
+		sb.WriteString(fmt.Sprintf("%d finding(s) are commented on the changed lines.\n\n", inline))
+	case len(review.Findings) == 0:
+		sb.WriteString("No findings.\n")
+	}
+	markdownFindings(&sb, review.Findings)
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,23 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComment(t *testing.T) {
+	assert.Equal(t, "**minor** (bug), lines 12-14: The error is ignored\n\n"+
+		"Suggestion:\n\n```\nif err != nil {\n\treturn err\n}\n```", Comment(sample.Findings[0]))
+	assert.Equal(t, "**critical** (security): The path is not sanitized", Comment(sample.Findings[2]))
+}
+
+func TestCommentSummary(t *testing.T) {
+	assert.Equal(t, "## Code review\n\nAdds a cache.\n\n"+
+		"2 finding(s) are commented on the changed lines.\n\n"+
+		"### `README.md`\n\n"+
+		"- **whole file** (info): Document the flag", CommentSummary(Review{Summary: sample.Summary, Findings: sample.Findings[1:2]}, 2))
+	assert.Equal(t, "## Code review\n\nAdds a cache.\n\n"+
+		"1 finding(s) are commented on the changed lines.", CommentSummary(Review{Summary: sample.Summary}, 1))
+	assert.Equal(t, "## Code review\n\nNo summary.\n\nNo findings.", CommentSummary(Review{}, 0))
+}
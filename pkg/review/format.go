@@ -0,0 +1,151 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is an output format of a review
+type Format string
+
+const (
+	// FormatText is the answer of the model as it was generated
+	FormatText Format = "text"
+	// FormatJSON is the Review encoded as JSON
+	FormatJSON Format = "json"
+	// FormatSARIF is a SARIF 2.1.0 log for code scanning dashboards
+	FormatSARIF Format = "sarif"
+	// FormatMarkdown is the summary and the findings grouped by file, for humans
+	FormatMarkdown Format = "markdown"
+	// FormatCheckstyle is a checkstyle XML report for CI tools
+	FormatCheckstyle Format = "checkstyle"
+)
+
+// ParseFormat validates the name of an output format
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(s); format {
+	case FormatText, FormatJSON, FormatSARIF, FormatMarkdown, FormatCheckstyle:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, expected %q, %q, %q, %q or %q", s, FormatText, FormatJSON, FormatSARIF, FormatMarkdown, FormatCheckstyle)
+	}
+}
+
+// Encode writes a review in one of the structured formats. The text format is the answer of
+// the model itself, which is not rebuilt from the parsed review.
+func Encode(w io.Writer, format Format, review Review) error {
+	if review.Findings == nil {
+		review.Findings = []Finding{}
+	}
+
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(review)
+	case FormatSARIF:
+		return encodeSARIF(w, review)
+	case FormatMarkdown:
+		_, err := io.WriteString(w, markdown(review))
+		return err
+	case FormatCheckstyle:
+		return encodeCheckstyle(w, review)
+	default:
+		return fmt.Errorf("the %q format cannot be encoded from a parsed review", format)
+	}
+}
+
+// byFile groups findings by file, in the order the files first appear
+func byFile(findings []Finding) (files []string, grouped map[string][]Finding) {
+	grouped = make(map[string][]Finding)
+	for _, finding := range findings {
+		if _, found := grouped[finding.File]; !found {
+			files = append(files, finding.File)
+		}
+		grouped[finding.File] = append(grouped[finding.File], finding)
+	}
+	return files, grouped
+}
+
+// fullMessage is the message of a finding followed by its suggestion, for formats with a
+// single message per finding
+func fullMessage(finding Finding) string {
+	if finding.Suggestion == "" {
+		return finding.Message
+	}
+	return finding.Message + "\n\nSuggestion:\n" + finding.Suggestion
+}
+
+// lines describes the lines of a finding for humans
+func lines(finding Finding) string {
+	switch {
+	case finding.StartLine == 0:
+		return "whole file"
+	case finding.StartLine == finding.EndLine:
+		return fmt.Sprintf("line %d", finding.StartLine)
+	default:
+		return fmt.Sprintf("lines %d-%d", finding.StartLine, finding.EndLine)
+	}
+}
+
+// markdown renders the summary and the findings grouped by file
+func markdown(review Review) string {
+	var sb strings.Builder
+	sb.WriteString("## Summary\n\n")
+	if review.Summary != "" {
+		sb.WriteString(review.Summary + "\n\n")
+	} else {
+		sb.WriteString("No summary.\n\n")
+	}
+
+	sb.WriteString("## Findings\n\n")
+	if len(review.Findings) == 0 {
+		sb.WriteString("No findings.\n")
+		return sb.String()
+	}
+
+	markdownFindings(&sb, review.Findings)
+	return sb.String()
+}
+
+// markdownFindings renders findings grouped by file
+func markdownFindings(sb *strings.Builder, findings []Finding) {
+	files, grouped := byFile(findings)
+	for _, file := range files {
+		sb.WriteString(fmt.Sprintf("### `%s`\n\n", file))
+		for _, finding := range grouped[file] {
+			label := string(finding.Severity)
+			if finding.Category != "" {
+				label += ", " + finding.Category
+			}
+			sb.WriteString(fmt.Sprintf("- **%s** (%s): %s\n", lines(finding), label, indent(finding.Message, "  ")))
+			if finding.Suggestion != "" {
+				fence := codeFence(finding.Suggestion)
+				sb.WriteString(fmt.Sprintf("\n  Suggestion:\n\n  %s\n%s\n  %s\n", fence, indent("  "+finding.Suggestion, "  "), fence))
+			}
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// indent indents every line but the first, so that multi-line text stays inside a list item
+func indent(s, prefix string) string {
+	return strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+// codeFence returns a fence longer than any run of backticks in the code
+func codeFence(code string) string {
+	longest, run := 0, 0
+	for _, r := range code {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
@@ -0,0 +1,108 @@
+package review
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var sample = Review{
+	Summary: "Adds a cache.",
+	Findings: []Finding{
+		{File: "cache.go", StartLine: 12, EndLine: 14, Severity: SeverityMinor, Category: "bug", Message: "The error is ignored", Suggestion: "if err != nil {\n\treturn err\n}"},
+		{File: "README.md", Severity: SeverityInfo, Message: "Document the flag"},
+		{File: "cache.go", StartLine: 3, EndLine: 3, Severity: SeverityCritical, Category: "security", Message: "The path is not sanitized"},
+	},
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("sarif")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatSARIF, format)
+
+	_, err = ParseFormat("html")
+	assert.EqualError(t, err, `unknown format "html", expected "text", "json", "sarif", "markdown" or "checkstyle"`)
+}
+
+func TestEncode_JSON(t *testing.T) {
+	var sb strings.Builder
+	assert.NoError(t, Encode(&sb, FormatJSON, sample))
+
+	var decoded Review
+	assert.NoError(t, json.Unmarshal([]byte(sb.String()), &decoded))
+	assert.Equal(t, sample, decoded)
+
+	sb.Reset()
+	assert.NoError(t, Encode(&sb, FormatJSON, Review{}))
+	assert.JSONEq(t, `{"summary":"","findings":[]}`, sb.String())
+}
+
+func TestEncode_SARIF(t *testing.T) {
+	var sb strings.Builder
+	assert.NoError(t, Encode(&sb, FormatSARIF, sample))
+
+	var log sarifLog
+	assert.NoError(t, json.Unmarshal([]byte(sb.String()), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal(t, "code-review", run.Tool.Driver.Name)
+	assert.Equal(t, []string{"bug", "general", "security"}, []string{run.Tool.Driver.Rules[0].ID, run.Tool.Driver.Rules[1].ID, run.Tool.Driver.Rules[2].ID})
+	assert.Equal(t, sarifResult{
+		RuleID:  "bug",
+		Level:   "warning",
+		Message: sarifMessage{Text: "The error is ignored\n\nSuggestion:\nif err != nil {\n\treturn err\n}"},
+		Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: "cache.go"},
+			Region:           &sarifRegion{StartLine: 12, EndLine: 14},
+		}}},
+	}, run.Results[0])
+	assert.Equal(t, "note", run.Results[1].Level)
+	assert.Nil(t, run.Results[1].Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, "error", run.Results[2].Level)
+}
+
+func TestEncode_Checkstyle(t *testing.T) {
+	var sb strings.Builder
+	assert.NoError(t, Encode(&sb, FormatCheckstyle, sample))
+	assert.True(t, strings.HasPrefix(sb.String(), xml.Header))
+
+	var report checkstyleReport
+	assert.NoError(t, xml.Unmarshal([]byte(sb.String()), &report))
+	assert.Equal(t, []checkstyleFile{
+		{Name: "cache.go", Errors: []checkstyleError{
+			{Line: 12, Severity: "warning", Message: "The error is ignored\n\nSuggestion:\nif err != nil {\n\treturn err\n}", Source: "code-review.bug"},
+			{Line: 3, Severity: "error", Message: "The path is not sanitized", Source: "code-review.security"},
+		}},
+		{Name: "README.md", Errors: []checkstyleError{
+			{Severity: "info", Message: "Document the flag", Source: "code-review.general"},
+		}},
+	}, report.Files)
+}
+
+func TestEncode_Markdown(t *testing.T) {
+	var sb strings.Builder
+	assert.NoError(t, Encode(&sb, FormatMarkdown, sample))
+	assert.Equal(t, "## Summary\n\nAdds a cache.\n\n"+
+		"## Findings\n\n"+
+		"### `cache.go`\n\n"+
+		"- **lines 12-14** (minor, bug): The error is ignored\n"+
+		"\n  Suggestion:\n\n  ```\n  if err != nil {\n  \treturn err\n  }\n  ```\n"+
+		"- **line 3** (critical, security): The path is not sanitized\n\n"+
+		"### `README.md`\n\n"+
+		"- **whole file** (info): Document the flag\n\n", sb.String())
+
+	sb.Reset()
+	assert.NoError(t, Encode(&sb, FormatMarkdown, Review{}))
+	assert.Equal(t, "## Summary\n\nNo summary.\n\n## Findings\n\nNo findings.\n", sb.String())
+
+	assert.Equal(t, "````", codeFence("a ``` b"))
+}
+
+func TestEncode_Text(t *testing.T) {
+	assert.EqualError(t, Encode(&strings.Builder{}, FormatText, sample), `the "text" format cannot be encoded from a parsed review`)
+}
@@ -0,0 +1,214 @@
+package review
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Severity is how important a finding is
+type Severity string
+
+// Severities from the least to the most important
+const (
+	// SeverityInfo is a remark or an optional suggestion
+	SeverityInfo Severity = "info"
+	// SeverityMinor is a small departure from the conventions or best practices
+	SeverityMinor Severity = "minor"
+	// SeverityMajor is a likely bug or a problem that should be fixed before merging
+	SeverityMajor Severity = "major"
+	// SeverityCritical is a bug or security issue that breaks the code or puts users at risk
+	SeverityCritical Severity = "critical"
+)
+
+// severities lists the severities from the least to the most important
+var severities = []Severity{SeverityInfo, SeverityMinor, SeverityMajor, SeverityCritical}
+
+// ParseSeverity validates the name of a severity
+func ParseSeverity(s string) (Severity, error) {
+	for _, severity := range severities {
+		if Severity(s) == severity {
+			return severity, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity %q, expected %q, %q, %q or %q", s, SeverityInfo, SeverityMinor, SeverityMajor, SeverityCritical)
+}
+
+// rank orders severities, from 0 for info, and is -1 for unknown severities
+func (s Severity) rank() int {
+	for i, severity := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// AtLeast reports whether the severity is as important as the threshold or more
+func (s Severity) AtLeast(threshold Severity) bool {
+	return s.rank() >= threshold.rank()
+}
+
+// Review is the structured content of a review
+type Review struct {
+	Summary  string    `json:"summary"`
+	Findings []Finding `json:"findings"`
+}
+
+// Finding is a single issue or suggested change in a file
+type Finding struct {
+	File string `json:"file"`
+	// StartLine and EndLine are the inclusive range of lines of the new version of the file,
+	// both zero when the finding is about the whole file
+	StartLine  int      `json:"start_line,omitempty"`
+	EndLine    int      `json:"end_line,omitempty"`
+	Severity   Severity `json:"severity"`
+	Category   string   `json:"category,omitempty"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+var (
+	reviewPattern  = regexp.MustCompile(`(?s)<review>(.*)</review>`)
+	findingPattern = regexp.MustCompile(`(?s)<finding>(.*?)</finding>`)
+)
+
+// Parse extracts the summary and the findings of a review from an answer in the format
+// requested by the review prompt, ignoring any text around the <review> element. Elements
+// are matched by name rather than decoded as XML, as answers often contain unescaped code.
+// The error describes every problem found, so that it can be sent back for repair.
+func Parse(answer string) (Review, error) {
+	match := reviewPattern.FindStringSubmatch(answer)
+	if match == nil {
+		return Review{}, errors.New("the answer has no <review> element")
+	}
+	body := match[1]
+
+	summary, _ := element(body, "summary")
+	findings, found := element(body, "findings")
+	if !found {
+		return Review{}, errors.New("the review has no <findings> element")
+	}
+
+	review := Review{Summary: text(summary), Findings: []Finding{}}
+	var problems []string
+	for i, match := range findingPattern.FindAllStringSubmatch(findings, -1) {
+		finding, err := parseFinding(match[1])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("finding %d %v", i+1, err))
+			continue
+		}
+		review.Findings = append(review.Findings, finding)
+	}
+	if len(problems) > 0 {
+		return Review{}, errors.New(strings.Join(problems, "; "))
+	}
+	return review, nil
+}
+
+// CountAtLeast returns the number of findings as important as the threshold or more
+func (r Review) CountAtLeast(threshold Severity) int {
+	count := 0
+	for _, finding := range r.Findings {
+		if finding.Severity.AtLeast(threshold) {
+			count++
+		}
+	}
+	return count
+}
+
+// parseFinding parses and validates the elements of a <finding>
+func parseFinding(body string) (Finding, error) {
+	field := func(name string) string {
+		value, _ := element(body, name)
+		return text(value)
+	}
+
+	finding := Finding{
+		File:       field("file"),
+		Severity:   Severity(strings.ToLower(field("severity"))),
+		Category:   strings.ToLower(field("category")),
+		Message:    field("message"),
+		Suggestion: field("suggestion"),
+	}
+	if finding.File == "" {
+		return Finding{}, errors.New("has no <file>")
+	}
+	if finding.Message == "" {
+		return Finding{}, errors.New("has no <message>")
+	}
+	if _, err := ParseSeverity(string(finding.Severity)); err != nil {
+		return Finding{}, fmt.Errorf("has an %v", err)
+	}
+
+	var err error
+	if finding.StartLine, err = lineNumber(field("start_line")); err != nil {
+		return Finding{}, fmt.Errorf("has an invalid <start_line>: %v", err)
+	}
+	if finding.EndLine, err = lineNumber(field("end_line")); err != nil {
+		return Finding{}, fmt.Errorf("has an invalid <end_line>: %v", err)
+	}
+	if finding.EndLine == 0 {
+		finding.EndLine = finding.StartLine
+	}
+	if finding.EndLine < finding.StartLine {
+		return Finding{}, fmt.Errorf("ends at line %d before it starts at line %d", finding.EndLine, finding.StartLine)
+	}
+	return finding, nil
+}
+
+// lineNumber parses an optional line number, which is zero when empty
+func lineNumber(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	line, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	if line < 0 {
+		return 0, fmt.Errorf("%d is negative", line)
+	}
+	return line, nil
+}
+
+// element returns the content of the first element with the given name
+func element(s, name string) (string, bool) {
+	start := strings.Index(s, "<"+name+">")
+	if start < 0 {
+		return "", false
+	}
+	s = s[start+len(name)+2:]
+	end := strings.Index(s, "</"+name+">")
+	if end < 0 {
+		return "", false
+	}
+	return s[:end], true
+}
+
+// text returns the text of an element, keeping CDATA sections as they are and unescaping
+// entities elsewhere
+func text(s string) string {
+	var sb strings.Builder
+	for {
+		start := strings.Index(s, "<![CDATA[")
+		if start < 0 {
+			sb.WriteString(html.UnescapeString(s))
+			break
+		}
+		sb.WriteString(html.UnescapeString(s[:start]))
+		s = s[start+len("<![CDATA["):]
+
+		end := strings.Index(s, "]]>")
+		if end < 0 {
+			sb.WriteString(s)
+			break
+		}
+		sb.WriteString(s[:end])
+		s = s[end+len("]]>"):]
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,132 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		answer   string
+		expected Review
+		err      string
+	}{
+		{
+			name: "Review with findings",
+			answer: `Here is my review:
+<review>
+<style_and_conventions>Consistent.</style_and_conventions>
+<summary>Adds a cache &amp; a flag.</summary>
+<findings>
+<finding>
+  <file>pkg/cache/cache.go</file>
+  <start_line>12</start_line>
+  <end_line>14</end_line>
+  <severity>Major</severity>
+  <category>bug</category>
+  <message><![CDATA[The error of os.Remove is ignored when a < b]]></message>
+  <suggestion><![CDATA[if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+	return err
+}]]></suggestion>
+</finding>
+<finding>
+  <file>README.md</file>
+  <start_line>3</start_line>
+  <severity>info</severity>
+  <category>comments</category>
+  <message>Mention the &lt;dir&gt; flag</message>
+  <suggestion></suggestion>
+</finding>
+<finding>
+  <file>go.mod</file>
+  <severity>critical</severity>
+  <message>The module requires a newer Go version</message>
+</finding>
+</findings>
+</review>`,
+			expected: Review{
+				Summary: "Adds a cache & a flag.",
+				Findings: []Finding{
+					{
+						File:       "pkg/cache/cache.go",
+						StartLine:  12,
+						EndLine:    14,
+						Severity:   SeverityMajor,
+						Category:   "bug",
+						Message:    "The error of os.Remove is ignored when a < b",
+						Suggestion: "if err := os.Remove(path); err != nil && !os.IsNotExist(err) {\n\treturn err\n}",
+					},
+					{File: "README.md", StartLine: 3, EndLine: 3, Severity: SeverityInfo, Category: "comments", Message: "Mention the <dir> flag"},
+					{File: "go.mod", Severity: SeverityCritical, Message: "The module requires a newer Go version"},
+				},
+			},
+		},
+		{
+			name:     "Review without findings",
+			answer:   "<review><summary>Looks good.</summary><findings>\n</findings></review>",
+			expected: Review{Summary: "Looks good.", Findings: []Finding{}},
+		},
+		{
+			name:   "No review",
+			answer: "I cannot review this change.",
+			err:    "the answer has no <review> element",
+		},
+		{
+			name:   "No findings element",
+			answer: "<review><summary>Looks good.</summary></review>",
+			err:    "the review has no <findings> element",
+		},
+		{
+			name: "Invalid findings",
+			answer: `<review><findings>
+<finding><file>a.go</file><severity>blocker</severity><message>m</message></finding>
+<finding><file>a.go</file><start_line>ten</start_line><severity>info</severity><message>m</message></finding>
+<finding><file>a.go</file><start_line>10</start_line><end_line>8</end_line><severity>info</severity><message>m</message></finding>
+<finding><severity>info</severity><message>m</message></finding>
+<finding><file>a.go</file><severity>info</severity></finding>
+</findings></review>`,
+			err: `finding 1 has an unknown severity "blocker", expected "info", "minor", "major" or "critical"; ` +
+				`finding 2 has an invalid <start_line>: "ten" is not a number; ` +
+				`finding 3 ends at line 8 before it starts at line 10; ` +
+				`finding 4 has no <file>; ` +
+				`finding 5 has no <message>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review, err := Parse(tt.answer)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, review)
+		})
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	severity, err := ParseSeverity("major")
+	assert.NoError(t, err)
+	assert.Equal(t, SeverityMajor, severity)
+
+	_, err = ParseSeverity("error")
+	assert.EqualError(t, err, `unknown severity "error", expected "info", "minor", "major" or "critical"`)
+
+	assert.True(t, SeverityCritical.AtLeast(SeverityMajor))
+	assert.True(t, SeverityMajor.AtLeast(SeverityMajor))
+	assert.False(t, SeverityMinor.AtLeast(SeverityMajor))
+	assert.True(t, SeverityInfo.AtLeast(SeverityInfo))
+
+	review := Review{Findings: []Finding{
+		{Severity: SeverityInfo},
+		{Severity: SeverityMajor},
+		{Severity: SeverityCritical},
+	}}
+	assert.Equal(t, 3, review.CountAtLeast(SeverityInfo))
+	assert.Equal(t, 2, review.CountAtLeast(SeverityMajor))
+	assert.Equal(t, 1, review.CountAtLeast(SeverityCritical))
+}
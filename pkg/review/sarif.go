@@ -0,0 +1,137 @@
+package review
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "code-review"
+	toolURI      = "https://github.com/lmquang/code-review"
+	// defaultCategory is the rule of findings without a category
+	defaultCategory = "general"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// encodeSARIF writes the findings as the results of a SARIF log, with a rule per category
+func encodeSARIF(w io.Writer, review Review) error {
+	var categories []string
+	seen := make(map[string]bool)
+	results := make([]sarifResult, 0, len(review.Findings))
+	for _, finding := range review.Findings {
+		category := finding.Category
+		if category == "" {
+			category = defaultCategory
+		}
+		if !seen[category] {
+			seen[category] = true
+			categories = append(categories, category)
+		}
+
+		location := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: finding.File},
+			},
+		}
+		if finding.StartLine > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: finding.StartLine, EndLine: finding.EndLine}
+		}
+		results = append(results, sarifResult{
+			RuleID:    category,
+			Level:     sarifLevel(finding.Severity),
+			Message:   sarifMessage{Text: fullMessage(finding)},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	sort.Strings(categories)
+	rules := make([]sarifRule, 0, len(categories))
+	for _, category := range categories {
+		rules = append(rules, sarifRule{ID: category, ShortDescription: sarifMessage{Text: "Code review findings about " + category}})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{Name: toolName, InformationURI: toolURI, Rules: rules},
+				},
+				Results: results,
+			},
+		},
+	})
+}
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(severity Severity) string {
+	switch severity {
+	case SeverityCritical, SeverityMajor:
+		return "error"
+	case SeverityMinor:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,90 @@
+package review
+
+import (
+	"path"
+	"strings"
+
+	"github.com/lmquang/code-review/pkg/diff"
+)
+
+// maxSnapDistance is how many lines away from the lines shown in the diff a finding can be
+// and still be moved onto the nearest of them
+const maxSnapDistance = 3
+
+// Snap checks the lines of every finding against the hunks of the reviewed files, keyed by
+// path, as models often get line numbers slightly wrong. A finding overlapping a hunk is
+// clipped to it, and a finding at most maxSnapDistance lines away from a hunk is moved to
+// its nearest line. Findings about files without hunks, such as deleted files, apply to the
+// whole file. The other findings, including findings about files that were not reviewed,
+// are dropped and returned separately.
+func Snap(r Review, hunks map[string][]diff.Hunk) (Review, []Finding) {
+	snapped := Review{Summary: r.Summary, Findings: []Finding{}}
+	var dropped []Finding
+	for _, finding := range r.Findings {
+		file, found := lookupFile(finding.File, hunks)
+		if !found {
+			dropped = append(dropped, finding)
+			continue
+		}
+		finding.File = file
+
+		finding, ok := snapFinding(finding, hunks[file])
+		if !ok {
+			dropped = append(dropped, finding)
+			continue
+		}
+		snapped.Findings = append(snapped.Findings, finding)
+	}
+	return snapped, dropped
+}
+
+// lookupFile finds the path of a finding among the reviewed files, tolerating the './', 'a/'
+// and 'b/' prefixes models sometimes add
+func lookupFile(file string, hunks map[string][]diff.Hunk) (string, bool) {
+	cleaned := path.Clean(file)
+	for _, candidate := range []string{file, cleaned, strings.TrimPrefix(cleaned, "b/"), strings.TrimPrefix(cleaned, "a/")} {
+		if _, found := hunks[candidate]; found {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// snapFinding moves a finding onto the lines of the new version of the file shown in its hunks
+func snapFinding(finding Finding, hunks []diff.Hunk) (Finding, bool) {
+	type lineRange struct{ start, end int }
+	var ranges []lineRange
+	for _, hunk := range hunks {
+		// Hunks that only delete lines show no line of the new version
+		if hunk.NewLines > 0 {
+			ranges = append(ranges, lineRange{hunk.NewStart, hunk.NewStart + hunk.NewLines - 1})
+		}
+	}
+	if finding.StartLine == 0 || len(ranges) == 0 {
+		finding.StartLine, finding.EndLine = 0, 0
+		return finding, true
+	}
+
+	for _, r := range ranges {
+		if finding.StartLine <= r.end && finding.EndLine >= r.start {
+			finding.StartLine = max(finding.StartLine, r.start)
+			finding.EndLine = min(finding.EndLine, r.end)
+			return finding, true
+		}
+	}
+
+	nearest, distance := 0, maxSnapDistance+1
+	for _, r := range ranges {
+		switch {
+		case finding.EndLine < r.start && r.start-finding.EndLine < distance:
+			nearest, distance = r.start, r.start-finding.EndLine
+		case finding.StartLine > r.end && finding.StartLine-r.end < distance:
+			nearest, distance = r.end, finding.StartLine-r.end
+		}
+	}
+	if nearest == 0 {
+		return finding, false
+	}
+	finding.StartLine, finding.EndLine = nearest, nearest
+	return finding, true
+}
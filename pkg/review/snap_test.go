@@ -0,0 +1,50 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lmquang/code-review/pkg/diff"
+)
+
+func TestSnap(t *testing.T) {
+	hunks := map[string][]diff.Hunk{
+		"main.go": {
+			{OldStart: 8, OldLines: 4, NewStart: 8, NewLines: 5},
+			{OldStart: 30, OldLines: 3, NewStart: 31, NewLines: 0},
+			{OldStart: 40, OldLines: 2, NewStart: 40, NewLines: 3},
+		},
+		"old.go": nil,
+	}
+
+	snapped, dropped := Snap(Review{
+		Summary: "s",
+		Findings: []Finding{
+			{File: "main.go", StartLine: 9, EndLine: 10, Message: "inside"},
+			{File: "main.go", StartLine: 6, EndLine: 9, Message: "overlapping"},
+			{File: "main.go", StartLine: 14, EndLine: 14, Message: "close after"},
+			{File: "./main.go", StartLine: 37, EndLine: 38, Message: "close before"},
+			{File: "b/main.go", StartLine: 25, EndLine: 25, Message: "far"},
+			{File: "main.go", Message: "whole file"},
+			{File: "old.go", StartLine: 3, EndLine: 5, Message: "deleted file"},
+			{File: "other.go", StartLine: 1, EndLine: 1, Message: "not reviewed"},
+		},
+	}, hunks)
+
+	assert.Equal(t, Review{
+		Summary: "s",
+		Findings: []Finding{
+			{File: "main.go", StartLine: 9, EndLine: 10, Message: "inside"},
+			{File: "main.go", StartLine: 8, EndLine: 9, Message: "overlapping"},
+			{File: "main.go", StartLine: 12, EndLine: 12, Message: "close after"},
+			{File: "main.go", StartLine: 40, EndLine: 40, Message: "close before"},
+			{File: "main.go", Message: "whole file"},
+			{File: "old.go", Message: "deleted file"},
+		},
+	}, snapped)
+	assert.Equal(t, []Finding{
+		{File: "main.go", StartLine: 25, EndLine: 25, Message: "far"},
+		{File: "other.go", StartLine: 1, EndLine: 1, Message: "not reviewed"},
+	}, dropped)
+}